@@ -6,7 +6,6 @@ import (
 	"fmt"
 	httpRequest "github.com/Songtingsen/go-utils/request"
 	"github.com/zeromicro/go-zero/core/logc"
-	"net/http"
 	"time"
 )
 
@@ -15,6 +14,9 @@ const (
 	ResponseOkCode = 0
 )
 
+// httpClient 请求飞书使用的客户端
+var httpClient = httpRequest.NewClient(httpRequest.WithTimeout(2 * time.Second))
+
 type BotMessage struct {
 	// BotSource 机器人资源节点
 	BotSource string
@@ -101,24 +103,13 @@ func (l *BotMessage) SendTextMsg(ctx context.Context, content string, at []At) e
 		"content":  string(textByte),
 	}
 
-	// 设置header
-	header := map[string]string{"Content-Type": "application/json"}
-
-	// 请求飞书发送消息
-	resByte, err := httpRequest.DoRequest(ctx, l.BotSource, http.MethodPost, message, header, 2*time.Second)
+	// 请求飞书发送消息并接收结果
+	res, err := httpRequest.PostJSON[Response](ctx, httpClient, l.BotSource, message, nil)
 	if err != nil {
 		logc.Errorf(ctx, "请求飞书发送消息失败：%s", err)
 		return err
 	}
-	logc.Infof(ctx, "请求飞书发送消息结果：%s", string(resByte))
-
-	// 接收结果
-	var res Response
-	err = json.Unmarshal(resByte, &res)
-	if err != nil {
-		logc.Errorf(ctx, "接收结果失败：%s", err)
-		return err
-	}
+	logc.Infof(ctx, "请求飞书发送消息结果：%+v", res)
 	if res.Code != ResponseOkCode {
 		logc.Errorf(ctx, "消息发送失败：%s", res.Msg)
 		return err
@@ -168,24 +159,13 @@ func (l *BotMessage) SendRichTextMessage(ctx context.Context, content RichTextCo
 		"content":  string(textByte),
 	}
 
-	// 设置header
-	header := map[string]string{"Content-Type": "application/json"}
-
-	// 请求飞书发送消息
-	resByte, err := httpRequest.DoRequest(ctx, l.BotSource, http.MethodPost, message, header, 2*time.Second)
+	// 请求飞书发送消息并接收结果
+	res, err := httpRequest.PostJSON[Response](ctx, httpClient, l.BotSource, message, nil)
 	if err != nil {
 		logc.Errorf(ctx, "请求飞书发送消息失败：%s", err)
 		return err
 	}
-	logc.Infof(ctx, "请求飞书发送消息结果：%s", string(resByte))
-
-	// 接收结果
-	var res Response
-	err = json.Unmarshal(resByte, &res)
-	if err != nil {
-		logc.Errorf(ctx, "接收结果失败：%s", err)
-		return err
-	}
+	logc.Infof(ctx, "请求飞书发送消息结果：%+v", res)
 	if res.Code != ResponseOkCode {
 		logc.Errorf(ctx, "消息发送失败：%s", res.Msg)
 		return err
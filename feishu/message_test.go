@@ -3,13 +3,33 @@ package feishu
 import (
 	"context"
 	"fmt"
+	httpRequest "github.com/Songtingsen/go-utils/request"
+	"os"
 	"strconv"
 	"testing"
+	"time"
 )
 
-const (
-	hookUrl = "飞书hook url"
-)
+// hookUrl 测试使用的机器人地址，设置 FEISHU_HOOK_URL 环境变量时使用真实地址重新录制 testdata/feishu.yaml
+var hookUrl = "https://open.feishu.cn/open-apis/bot/v2/hook/test-token"
+
+// TestMain 使用录制的请求回放，测试不依赖网络
+func TestMain(m *testing.M) {
+	conf := httpRequest.CassetteConf{Path: "testdata/feishu.yaml", Mode: httpRequest.RecordModeReplay}
+	if realUrl := os.Getenv("FEISHU_HOOK_URL"); realUrl != "" {
+		conf.Mode = httpRequest.RecordModeRecord
+		conf.Replacements = map[string]string{realUrl: hookUrl}
+		hookUrl = realUrl
+	}
+	recorder, err := httpRequest.NewRecorder(conf)
+	if err != nil {
+		fmt.Println("磁带文件加载失败", err)
+		os.Exit(1)
+	}
+	httpClient = httpRequest.NewClient(httpRequest.WithTimeout(2*time.Second), httpRequest.WithTransport(recorder))
+
+	os.Exit(m.Run())
+}
 
 func TestBotMessage_SendMessage(t *testing.T) {
 	type fields struct {
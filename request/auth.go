@@ -0,0 +1,288 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"github.com/zeromicro/go-zero/core/syncx"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultRefreshBefore 默认提前刷新令牌的时间
+const defaultRefreshBefore = time.Minute
+
+// defaultTokenTimeout 默认获取令牌的超时时间
+const defaultTokenTimeout = 30 * time.Second
+
+// Authenticator 请求认证，为每一次实际发出的请求设置认证信息
+type Authenticator interface {
+	// Authenticate 为请求设置认证信息
+	Authenticate(req *http.Request) error
+}
+
+// Refresher 凭证可以刷新的认证，请求返回 401 时刷新凭证并重试一次
+type Refresher interface {
+	// Refresh 强制刷新凭证
+	Refresh(ctx context.Context) error
+}
+
+// WithAuth 设置请求认证，认证在用户中间件之后执行，重试与对冲的每一次请求都会重新认证
+func WithAuth(auth Authenticator) Option {
+	return func(c *Client) {
+		c.auth = auth
+	}
+}
+
+// authMiddleware 认证中间件，认证器实现 Refresher 时返回 401 后刷新凭证并重试一次
+func authMiddleware(auth Authenticator) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			retry := req.Clone(req.Context())
+			if err := auth.Authenticate(req); err != nil {
+				return nil, err
+			}
+			resp, err := next(req)
+			refresher, ok := auth.(Refresher)
+			if err != nil || !ok || resp.StatusCode != http.StatusUnauthorized {
+				return resp, err
+			}
+			// 请求体无法重新读取时不能重试
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				return resp, nil
+			}
+
+			if err = refresher.Refresh(req.Context()); err != nil {
+				return resp, nil
+			}
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+			if req.GetBody != nil {
+				if retry.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+			if err = auth.Authenticate(retry); err != nil {
+				return nil, err
+			}
+
+			return next(retry)
+		}
+	}
+}
+
+// basicAuth 固定用户名密码的 Basic 认证
+type basicAuth struct {
+	username string
+	password string
+}
+
+// BasicAuth 使用 Basic 认证
+func BasicAuth(username, password string) Authenticator {
+	return basicAuth{username: username, password: password}
+}
+
+// Authenticate 设置 Basic 认证请求头
+func (a basicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.username, a.password)
+
+	return nil
+}
+
+// bearerToken 固定令牌的 Bearer 认证
+type bearerToken string
+
+// BearerToken 使用固定令牌的 Bearer 认证
+func BearerToken(token string) Authenticator {
+	return bearerToken(token)
+}
+
+// Authenticate 设置 Bearer 认证请求头
+func (t bearerToken) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+
+	return nil
+}
+
+// ClientCredentialsConf OAuth2 客户端凭证模式配置
+type ClientCredentialsConf struct {
+	// TokenURL 获取令牌的地址
+	TokenURL string
+	// ClientID 客户端 id
+	ClientID string
+	// ClientSecret 客户端密钥
+	ClientSecret string
+	// Scopes 申请的权限范围
+	Scopes []string
+	// Params 获取令牌时的额外参数，例如 audience
+	Params map[string]string
+	// AuthInParams 客户端 id 与密钥放在请求参数中，默认使用 Basic 认证
+	AuthInParams bool
+	// RefreshBefore 令牌过期前多久开始在后台刷新，默认 1 分钟
+	RefreshBefore time.Duration
+	// Timeout 获取令牌的超时时间，默认 30 秒，令牌请求不随触发刷新的调用方 ctx 取消
+	Timeout time.Duration
+	// Client 获取令牌使用的客户端，为空时使用默认客户端，令牌请求最多记录摘要日志
+	Client *Client
+}
+
+// ClientCredentials OAuth2 客户端凭证模式认证，缓存令牌并在过期前刷新，同一时间只有一个刷新请求
+type ClientCredentials struct {
+	conf       ClientCredentialsConf
+	flight     syncx.SingleFlight
+	mu         sync.RWMutex
+	token      string
+	expires    time.Time
+	refreshAt  time.Time
+	refreshing int32
+}
+
+// oauth2Token 令牌接口返回内容
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// NewClientCredentials 初始化 OAuth2 客户端凭证模式认证
+func NewClientCredentials(conf ClientCredentialsConf) *ClientCredentials {
+	if conf.RefreshBefore <= 0 {
+		conf.RefreshBefore = defaultRefreshBefore
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultTokenTimeout
+	}
+
+	return &ClientCredentials{conf: conf, flight: syncx.NewSingleFlight()}
+}
+
+// Authenticate 设置 Bearer 认证请求头，没有可用令牌时同步获取
+func (a *ClientCredentials) Authenticate(req *http.Request) error {
+	token, err := a.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", token)
+
+	return nil
+}
+
+// Token 获取 Authorization 请求头的值，令牌即将过期时在后台刷新并继续使用当前令牌
+func (a *ClientCredentials) Token(ctx context.Context) (string, error) {
+	a.mu.RLock()
+	token, expires, refreshAt := a.token, a.expires, a.refreshAt
+	a.mu.RUnlock()
+
+	now := time.Now()
+	switch {
+	case token == "" || !now.Before(expires):
+		return a.refresh(ctx)
+	case !now.Before(refreshAt) && atomic.CompareAndSwapInt32(&a.refreshing, 0, 1):
+		// 同一时间只有一个后台刷新
+		go func() {
+			defer atomic.StoreInt32(&a.refreshing, 0)
+			_, _ = a.refresh(context.WithoutCancel(ctx))
+		}()
+	}
+
+	return token, nil
+}
+
+// Refresh 强制刷新令牌，实现 Refresher
+func (a *ClientCredentials) Refresh(ctx context.Context) error {
+	_, err := a.refresh(ctx)
+
+	return err
+}
+
+// refresh 获取新令牌，并发调用时只有一个请求，令牌请求不随首个调用方的 ctx 取消，调用方的 ctx 结束时直接返回
+func (a *ClientCredentials) refresh(ctx context.Context) (string, error) {
+	type result struct {
+		token string
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		val, err := a.flight.Do("token", func() (any, error) {
+			fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.conf.Timeout)
+			defer cancel()
+			return a.fetch(fetchCtx)
+		})
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		done <- result{token: val.(string)}
+	}()
+
+	select {
+	case res := <-done:
+		return res.token, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// tokenClient 获取令牌使用的客户端，请求参数与返回内容包含密钥和令牌，最多只记录摘要日志且不输出 curl 命令
+func (a *ClientCredentials) tokenClient() *Client {
+	client := a.conf.Client
+	if client == nil {
+		client = defaultClient
+	}
+	quiet := *client
+	quiet.logLevel = max(quiet.logLevel, LogLevelSummary)
+	quiet.curlOnError = false
+
+	return &quiet
+}
+
+// fetch 请求令牌接口并缓存令牌
+func (a *ClientCredentials) fetch(ctx context.Context) (string, error) {
+	params := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.conf.Scopes) > 0 {
+		params.Set("scope", strings.Join(a.conf.Scopes, " "))
+	}
+	for k, v := range a.conf.Params {
+		params.Set(k, v)
+	}
+	header := map[string]string{"Content-Type": ApplicationForm}
+	if a.conf.AuthInParams {
+		params.Set("client_id", a.conf.ClientID)
+		params.Set("client_secret", a.conf.ClientSecret)
+	} else {
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(url.QueryEscape(a.conf.ClientID), url.QueryEscape(a.conf.ClientSecret))
+		header["Authorization"] = req.Header.Get("Authorization")
+	}
+
+	result, err := PostJSON[oauth2Token](ctx, a.tokenClient(), a.conf.TokenURL, params, header)
+	if err != nil {
+		return "", err
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("令牌接口没有返回 access_token")
+	}
+	tokenType := result.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	token := tokenType + " " + result.AccessToken
+	lifetime := time.Duration(result.ExpiresIn) * time.Second
+	if result.ExpiresIn <= 0 {
+		// 没有返回有效期时视为长期有效，依赖 401 时刷新
+		lifetime = 365 * 24 * time.Hour
+	}
+	now := time.Now()
+
+	a.mu.Lock()
+	a.token, a.expires = token, now.Add(lifetime)
+	// 有效期较短时在有效期过半后刷新，避免每次请求都触发刷新
+	a.refreshAt = now.Add(max(lifetime-a.conf.RefreshBefore, lifetime/2))
+	a.mu.Unlock()
+
+	return token, nil
+}
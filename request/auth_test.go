@@ -0,0 +1,195 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试 Basic 与 Bearer 认证
+func TestWithAuth_Static(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		auth Authenticator
+		want string
+	}{
+		{BasicAuth("user", "pass"), "Basic dXNlcjpwYXNz"},
+		{BearerToken("abc"), "Bearer abc"},
+	}
+	for _, tt := range tests {
+		_, _ = NewClient(WithAuth(tt.auth)).Get(context.Background(), server.URL, nil, nil)
+		if authorization != tt.want {
+			t.Errorf("Authorization = %q; want %q", authorization, tt.want)
+		}
+	}
+}
+
+// tokenServer 模拟令牌接口与业务接口，令牌每次获取后递增
+func tokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	var issued int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "id" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "read write" {
+			t.Errorf("令牌请求参数错误：%s %s %v", id, secret, r.Form)
+		}
+		time.Sleep(10 * time.Millisecond)
+		n := atomic.AddInt32(&issued, 1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": fmt.Sprintf("t%d", n), "token_type": "bearer", "expires_in": expiresIn})
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		// 只接受最新的令牌
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer t%d", atomic.LoadInt32(&issued)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	return httptest.NewServer(mux), &issued
+}
+
+// 测试并发请求只获取一次令牌，令牌缓存后复用
+func TestClientCredentials(t *testing.T) {
+	server, issued := tokenServer(t, 3600)
+	defer server.Close()
+
+	auth := NewClientCredentials(ClientCredentialsConf{
+		TokenURL:     server.URL + "/token",
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+	client := NewClient(WithAuth(auth))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, err := client.Get(context.Background(), server.URL+"/api", nil, nil); err != nil || string(body) != "ok" {
+				t.Errorf("Get() = %q, %v", body, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if *issued != 1 {
+		t.Errorf("获取令牌 %d 次; want 1", *issued)
+	}
+
+	// 令牌被服务端作废后返回 401，刷新令牌并重试一次
+	atomic.AddInt32(issued, 1)
+	if body, err := client.Get(context.Background(), server.URL+"/api", nil, nil); err != nil || string(body) != "ok" {
+		t.Errorf("401 后重试 Get() = %q, %v", body, err)
+	}
+}
+
+// 测试令牌即将过期时在后台提前刷新
+func TestClientCredentials_ProactiveRefresh(t *testing.T) {
+	server, issued := tokenServer(t, 2)
+	defer server.Close()
+
+	auth := NewClientCredentials(ClientCredentialsConf{
+		TokenURL:      server.URL + "/token",
+		ClientID:      "id",
+		ClientSecret:  "secret",
+		Scopes:        []string{"read", "write"},
+		RefreshBefore: 2 * time.Second,
+	})
+	token, err := auth.Token(context.Background())
+	if err != nil || token != "Bearer t1" {
+		t.Fatalf("Token() = %q, %v", token, err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	// 有效期过半，返回当前令牌并在后台刷新
+	if token, _ = auth.Token(context.Background()); token != "Bearer t1" {
+		t.Errorf("Token() = %q; want Bearer t1", token)
+	}
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(issued) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if token, _ = auth.Token(context.Background()); !strings.HasSuffix(token, "t2") {
+		t.Errorf("后台刷新后 Token() = %q; want Bearer t2", token)
+	}
+}
+
+// 测试首个调用方取消时不影响同时等待令牌的其他调用方
+func TestClientCredentials_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte(`{"access_token":"t1","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	auth := NewClientCredentials(ClientCredentialsConf{TokenURL: server.URL, ClientID: "id", ClientSecret: "secret"})
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := auth.Token(ctx)
+		first <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	second := make(chan string, 1)
+	go func() {
+		token, _ := auth.Token(context.Background())
+		second <- token
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("取消的调用方 error = %v; want context.Canceled", err)
+	}
+	close(release)
+	if token := <-second; token != "Bearer t1" {
+		t.Errorf("其他调用方 Token() = %q; want Bearer t1", token)
+	}
+}
+
+// 测试令牌请求的日志中不包含客户端密钥与令牌
+func TestClientCredentials_Log(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_secret") != "SECRET_CLIENT" {
+			t.Errorf("client_secret = %q", r.FormValue("client_secret"))
+		}
+		_, _ = w.Write([]byte(`{"access_token":"SECRET_TOKEN","refresh_token":"SECRET_REFRESH","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	logger := &memoryLogger{}
+	auth := NewClientCredentials(ClientCredentialsConf{
+		TokenURL:     server.URL,
+		ClientID:     "id",
+		ClientSecret: "SECRET_CLIENT",
+		AuthInParams: true,
+		Client:       NewClient(WithLogger(logger), WithCurlOnError()),
+	})
+	if token, err := auth.Token(context.Background()); err != nil || token != "Bearer SECRET_TOKEN" {
+		t.Fatalf("Token() = %q, %v", token, err)
+	}
+
+	output := logger.String()
+	if !strings.Contains(output, server.URL) {
+		t.Errorf("日志中没有令牌请求：%s", output)
+	}
+	for _, secret := range []string{"SECRET_CLIENT", "SECRET_TOKEN", "SECRET_REFRESH"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("日志中包含 %s：%s", secret, output)
+		}
+	}
+}
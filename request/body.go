@@ -0,0 +1,195 @@
+package request
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// defaultMaxDecompressedSize 开启解压且没有设置最大返回内容时，解压后内容的默认上限
+const defaultMaxDecompressedSize = 64 << 20
+
+// zstdMaxWindow zstd 解压允许的最大窗口，与 RFC 9659 对 http 内容编码的要求一致
+const zstdMaxWindow = 8 << 20
+
+// ErrBodyTooLarge 返回内容（解压后）超过最大限制
+var ErrBodyTooLarge = errors.New("返回数据超过最大限制")
+
+// ErrUnexpectedContentType 返回内容的 Content-Type 与预期不符
+var ErrUnexpectedContentType = errors.New("返回数据类型不符合预期")
+
+// Decoder 根据压缩格式创建解压读取器
+type Decoder func(r io.Reader) (io.Reader, error)
+
+// WithExpectContentType 校验状态码可接受的返回的 Content-Type，不匹配时同时返回 Response 与 ErrUnexpectedContentType
+// 忽略 charset 等参数，支持 type/* 通配，例如 application/json、text/*，没有返回内容时不校验
+func WithExpectContentType(types ...string) Option {
+	return func(c *Client) {
+		c.expectTypes = types
+	}
+}
+
+// WithDecompression 开启返回内容解压，请求时按已注册的格式设置 Accept-Encoding，内置 br、deflate、gzip 与 zstd，
+// 其他格式通过 WithDecoder 注册，返回未注册的压缩格式时请求返回错误
+// Download、Subscribe 等流式读取的请求不受影响，仍由 http.Transport 透明解压 gzip
+// maxSize 为解压后内容的上限，用于防止压缩炸弹，与 WithMaxBodySize 都设置时取较小的值，两者都未设置时为 64MB
+func WithDecompression(maxSize int64) Option {
+	return func(c *Client) {
+		c.decompressor().maxSize = maxSize
+	}
+}
+
+// WithDecoder 注册解压格式并开启返回内容解压，与 WithDecompression 的先后顺序无关，可以覆盖内置的格式
+func WithDecoder(encoding string, decoder Decoder) Option {
+	return func(c *Client) {
+		c.decompressor().decoders[strings.ToLower(encoding)] = decoder
+	}
+}
+
+// decompressor 获取解压配置，不存在时创建并注册内置的压缩格式
+func (c *Client) decompressor() *decompressor {
+	if c.decompress == nil {
+		c.decompress = &decompressor{
+			decoders: map[string]Decoder{
+				"br":      newBrotliReader,
+				"deflate": newDeflateReader,
+				"gzip":    newGzipReader,
+				"zstd":    newZstdReader,
+			},
+		}
+	}
+
+	return c.decompress
+}
+
+// decompressor 返回内容解压配置
+type decompressor struct {
+	maxSize  int64
+	decoders map[string]Decoder
+}
+
+// acceptEncoding 已注册的压缩格式
+func (d *decompressor) acceptEncoding() string {
+	encodings := make([]string, 0, len(d.decoders))
+	for encoding := range d.decoders {
+		encodings = append(encodings, encoding)
+	}
+	sort.Strings(encodings)
+
+	return strings.Join(encodings, ", ")
+}
+
+// limit 解压后内容的上限，maxSize 与 WithMaxBodySize 都设置时取较小的值，都未设置时为 64MB
+func (d *decompressor) limit(maxBodySize int64) int64 {
+	switch {
+	case d.maxSize > 0 && maxBodySize > 0:
+		return min(d.maxSize, maxBodySize)
+	case d.maxSize > 0:
+		return d.maxSize
+	case maxBodySize > 0:
+		return maxBodySize
+	default:
+		return defaultMaxDecompressedSize
+	}
+}
+
+// decode 按 Content-Encoding 逆序解压，解压后删除 Content-Encoding 与 Content-Length
+func (d *decompressor) decode(resp *http.Response) (io.Reader, error) {
+	var reader io.Reader = resp.Body
+	encodings := strings.Split(resp.Header.Get("Content-Encoding"), ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+		if encoding == "" || encoding == "identity" {
+			continue
+		}
+		decoder, ok := d.decoders[encoding]
+		if !ok {
+			return nil, fmt.Errorf("不支持的压缩格式：%s", encoding)
+		}
+		var err error
+		if reader, err = decoder(reader); err != nil {
+			return nil, fmt.Errorf("%s 解压失败：%w", encoding, err)
+		}
+	}
+	if reader != resp.Body {
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+	}
+
+	return reader, nil
+}
+
+// newGzipReader gzip 解压
+func newGzipReader(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+// newDeflateReader deflate 解压，兼容带 zlib 头与不带 zlib 头的数据
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+
+	return flate.NewReader(br), nil
+}
+
+// newBrotliReader br 解压
+func newBrotliReader(r io.Reader) (io.Reader, error) {
+	return brotli.NewReader(r), nil
+}
+
+// newZstdReader zstd 解压，同步解压不启动后台协程，读取结束时释放解压器
+func newZstdReader(r io.Reader) (io.Reader, error) {
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(zstdMaxWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	return &zstdReader{decoder: decoder}, nil
+}
+
+// zstdReader 读取结束或出错时关闭的 zstd 解压器
+type zstdReader struct {
+	decoder *zstd.Decoder
+}
+
+// Read 读取解压后的内容
+func (z *zstdReader) Read(p []byte) (int, error) {
+	n, err := z.decoder.Read(p)
+	if err != nil {
+		z.decoder.Close()
+	}
+
+	return n, err
+}
+
+// checkContentType 校验返回的 Content-Type
+func (c *Client) checkContentType(resp *Response) error {
+	if len(c.expectTypes) == 0 || len(resp.Body) == 0 {
+		return nil
+	}
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		for _, expect := range c.expectTypes {
+			prefix, wildcard := strings.CutSuffix(strings.ToLower(expect), "/*")
+			if mediaType == strings.ToLower(expect) || wildcard && strings.HasPrefix(mediaType, prefix+"/") {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w：%q，预期：%s", ErrUnexpectedContentType, contentType, strings.Join(c.expectTypes, "、"))
+}
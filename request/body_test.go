@@ -0,0 +1,183 @@
+package request
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// compress 按格式压缩内容
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		w, _ = zstd.NewWriter(&buf)
+	default:
+		t.Fatalf("未知的压缩格式 %s", encoding)
+	}
+	_, _ = w.Write(data)
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+// 测试解压内置格式的返回内容
+func TestWithDecompression(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"name":"tom"}`, 100))
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate", "br", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			var acceptEncoding string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				acceptEncoding = r.Header.Get("Accept-Encoding")
+				w.Header().Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+				_, _ = w.Write(compress(t, encoding, payload))
+			}))
+			defer server.Close()
+
+			resp, err := NewClient(WithDecompression(0)).Do(context.Background(), http.MethodGet, server.URL, nil, nil)
+			if err != nil || !bytes.Equal(resp.Body, payload) {
+				t.Fatalf("Do() body 长度 = %d, error = %v", len(resp.Body), err)
+			}
+			if acceptEncoding != "br, deflate, gzip, zstd" || resp.Header.Get("Content-Encoding") != "" {
+				t.Errorf("Accept-Encoding = %q, Content-Encoding = %q", acceptEncoding, resp.Header.Get("Content-Encoding"))
+			}
+		})
+	}
+}
+
+// 测试解压后内容超过限制时返回 ErrBodyTooLarge，注册自定义解压格式
+func TestWithDecompression_Bomb(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", r.URL.Query().Get("encoding"))
+		if r.URL.Query().Get("encoding") == "gzip" {
+			// 10MB 的 0 压缩后只有约 10KB
+			_, _ = w.Write(compress(t, "gzip", make([]byte, 10<<20)))
+			return
+		}
+		_, _ = w.Write([]byte("olleh"))
+	}))
+	defer server.Close()
+
+	reverse := func(r io.Reader) (io.Reader, error) {
+		data, err := io.ReadAll(r)
+		for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+			data[i], data[j] = data[j], data[i]
+		}
+		return bytes.NewReader(data), err
+	}
+	// WithDecoder 在 WithDecompression 之前同样生效
+	client := NewClient(WithDecoder("rev", reverse), WithDecompression(1<<20))
+	if _, err := client.Get(context.Background(), server.URL, map[string]any{"encoding": "gzip"}, nil); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("压缩炸弹 error = %v; want ErrBodyTooLarge", err)
+	}
+	if body, err := client.Get(context.Background(), server.URL, map[string]any{"encoding": "rev"}, nil); err != nil || string(body) != "hello" {
+		t.Errorf("自定义解压 Get() = %q, %v", body, err)
+	}
+	if _, err := client.Get(context.Background(), server.URL, map[string]any{"encoding": "lz4"}, nil); err == nil {
+		t.Errorf("未注册的压缩格式应返回错误")
+	}
+}
+
+// 测试解压上限与 WithMaxBodySize 取较小的值，未压缩的返回内容同样受限
+func TestWithDecompression_Limit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bytes.Repeat([]byte("a"), 1000))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+		fail bool
+	}{
+		{name: "WithMaxBodySize 较小", opts: []Option{WithMaxBodySize(10), WithDecompression(1 << 20)}, fail: true},
+		{name: "解压上限较小", opts: []Option{WithMaxBodySize(1 << 20), WithDecompression(10)}, fail: true},
+		{name: "都未超过", opts: []Option{WithMaxBodySize(1 << 20), WithDecompression(1 << 20)}},
+	}
+	for _, tt := range tests {
+		opts := append(tt.opts, WithLogger(&memoryLogger{}))
+		_, err := NewClient(opts...).Get(context.Background(), server.URL, nil, nil)
+		if errors.Is(err, ErrBodyTooLarge) != tt.fail {
+			t.Errorf("%s error = %v", tt.name, err)
+		}
+	}
+}
+
+// 测试开启解压后下载与订阅仍然拿到解压后的内容
+func TestWithDecompression_Stream(t *testing.T) {
+	payload := []byte(strings.Repeat("0123456789", 100))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := payload
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			body = []byte("data: ok\n\n")
+		}
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			body = compress(t, "gzip", body)
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithDecompression(0), WithLogger(&memoryLogger{}))
+	var buf bytes.Buffer
+	if _, err := client.Download(context.Background(), server.URL, &buf, nil); err != nil || !bytes.Equal(buf.Bytes(), payload) {
+		t.Errorf("Download() 内容长度 = %d, error = %v", buf.Len(), err)
+	}
+
+	var data string
+	err := client.Subscribe(context.Background(), server.URL+"/events", &SSEOptions{DisableReconnect: true}, func(event Event) error {
+		data = event.Data
+		return nil
+	})
+	if err != nil || data != "ok" {
+		t.Errorf("Subscribe() data = %q, error = %v", data, err)
+	}
+}
+
+// 测试 Content-Type 校验
+func TestWithExpectContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithExpectContentType(ApplicationJson, "text/*"))
+	tests := map[string]bool{
+		"application/json; charset=utf-8": true,
+		"text/plain":                      true,
+		"text/html":                       true,
+		"application/xml":                 false,
+		"":                                false,
+	}
+	for contentType, ok := range tests {
+		resp, err := client.Do(context.Background(), http.MethodGet, server.URL, map[string]any{"type": contentType}, nil)
+		if (err == nil) != ok || resp == nil {
+			t.Errorf("Content-Type %q error = %v", contentType, err)
+		}
+		if !ok && !errors.Is(err, ErrUnexpectedContentType) {
+			t.Errorf("Content-Type %q error = %v; want ErrUnexpectedContentType", contentType, err)
+		}
+	}
+}
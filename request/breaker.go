@@ -0,0 +1,276 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zeromicro/go-zero/core/breaker"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器处于打开状态，请求未发出
+var ErrCircuitOpen = errors.New("熔断器已打开")
+
+// Breaker 熔断器，go-zero 的 breaker.Breaker 可以直接使用
+type Breaker interface {
+	Allow() (breaker.Promise, error)
+}
+
+// ReleasablePromise 支持中性结果的请求结果回调，调用方主动取消时调用 Release，既不计成功也不计失败；
+// 未实现该接口的 Promise 在取消时不做任何回调
+type ReleasablePromise interface {
+	breaker.Promise
+	Release()
+}
+
+// BreakerConf 熔断配置，连续失败次数与错误率任一达到阈值即打开熔断器
+type BreakerConf struct {
+	// ConsecutiveFailures 连续失败次数阈值，0 表示不按连续失败次数熔断
+	ConsecutiveFailures int `json:",default=5"`
+	// ErrorRatio 统计窗口内的错误率阈值，取值 0~1，0 表示不按错误率熔断
+	ErrorRatio float64 `json:",default=0.5"`
+	// MinRequests 按错误率熔断时统计窗口内的最少请求数
+	MinRequests int `json:",default=20"`
+	// Window 错误率统计窗口
+	Window time.Duration `json:",default=10s"`
+	// OpenTimeout 熔断器打开后持续的时间，之后进入半开状态
+	OpenTimeout time.Duration `json:",default=5s"`
+	// HalfOpenRequests 半开状态允许的探测请求数，全部成功后关闭熔断器
+	HalfOpenRequests int `json:",default=1"`
+}
+
+// DefaultBreakerConf 默认熔断配置
+func DefaultBreakerConf() BreakerConf {
+	return BreakerConf{
+		ConsecutiveFailures: 5,
+		ErrorRatio:          0.5,
+		MinRequests:         20,
+		Window:              10 * time.Second,
+		OpenTimeout:         5 * time.Second,
+		HalfOpenRequests:    1,
+	}
+}
+
+// WithCircuitBreaker 按主机名启用熔断
+func WithCircuitBreaker(conf BreakerConf) Option {
+	return WithBreaker(func(host string) Breaker {
+		return NewCircuitBreaker(conf)
+	})
+}
+
+// WithBreaker 按主机名启用自定义熔断器，newBreaker 为每个主机创建一个熔断器
+func WithBreaker(newBreaker func(host string) Breaker) Option {
+	return func(c *Client) {
+		c.breakers = &breakerGroup{newBreaker: newBreaker, breakers: make(map[string]Breaker)}
+	}
+}
+
+// GoZeroBreaker 使用 go-zero 基于自适应算法的熔断器，配合 WithBreaker 使用
+func GoZeroBreaker(host string) Breaker {
+	return breaker.NewBreaker(breaker.WithName(host))
+}
+
+// breakerGroup 按主机名管理熔断器
+type breakerGroup struct {
+	mu         sync.Mutex
+	newBreaker func(host string) Breaker
+	breakers   map[string]Breaker
+}
+
+// get 获取主机对应的熔断器
+func (g *breakerGroup) get(host string) Breaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	b, ok := g.breakers[host]
+	if !ok {
+		b = g.newBreaker(host)
+		g.breakers[host] = b
+	}
+
+	return b
+}
+
+// middleware 熔断中间件，网络错误与 5xx 计为失败
+func (g *breakerGroup) middleware(next Handler) Handler {
+	return func(req *http.Request) (*http.Response, error) {
+		promise, err := g.get(req.URL.Host).Allow()
+		if err != nil {
+			if errors.Is(err, breaker.ErrServiceUnavailable) {
+				return nil, fmt.Errorf("%w：%s", ErrCircuitOpen, req.URL.Host)
+			}
+			return nil, err
+		}
+
+		resp, err := next(req)
+		switch {
+		case err != nil && errors.Is(err, context.Canceled):
+			// 调用方主动取消不计入成功或失败
+			if releasable, ok := promise.(ReleasablePromise); ok {
+				releasable.Release()
+			}
+		case err != nil:
+			promise.Reject(err.Error())
+		case resp.StatusCode >= http.StatusInternalServerError:
+			promise.Reject(strconv.Itoa(resp.StatusCode))
+		default:
+			promise.Accept()
+		}
+
+		return resp, err
+	}
+}
+
+// circuitState 熔断器状态
+type circuitState int
+
+const (
+	stateClosed circuitState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// CircuitBreaker 支持连续失败次数、错误率阈值与半开探测的熔断器
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	conf        BreakerConf
+	state       circuitState
+	consecutive int
+	total       int
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	probes      int
+	successes   int
+	generation  uint64
+}
+
+// NewCircuitBreaker 初始化熔断器
+func NewCircuitBreaker(conf BreakerConf) *CircuitBreaker {
+	if conf.HalfOpenRequests <= 0 {
+		conf.HalfOpenRequests = 1
+	}
+
+	return &CircuitBreaker{conf: conf, windowStart: time.Now()}
+}
+
+// Allow 判断是否允许请求，熔断器打开时返回 breaker.ErrServiceUnavailable
+func (b *CircuitBreaker) Allow() (breaker.Promise, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.state == stateOpen {
+		if now.Sub(b.openedAt) < b.conf.OpenTimeout {
+			return nil, breaker.ErrServiceUnavailable
+		}
+		b.setState(stateHalfOpen)
+		b.probes, b.successes = 0, 0
+	}
+	if b.state == stateHalfOpen {
+		if b.probes >= b.conf.HalfOpenRequests {
+			return nil, breaker.ErrServiceUnavailable
+		}
+		b.probes++
+	}
+
+	return circuitPromise{b: b, generation: b.generation}, nil
+}
+
+// onResult 记录请求结果并切换状态，状态切换前发出的请求结果不再计入
+func (b *CircuitBreaker) onResult(generation uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	now := time.Now()
+	if b.state == stateHalfOpen {
+		if !success {
+			b.open(now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.conf.HalfOpenRequests {
+			b.setState(stateClosed)
+			b.reset(now)
+		}
+		return
+	}
+	if b.state == stateOpen {
+		return
+	}
+
+	if b.conf.Window > 0 && now.Sub(b.windowStart) > b.conf.Window {
+		b.total, b.failures, b.windowStart = 0, 0, now
+	}
+	b.total++
+	if success {
+		b.consecutive = 0
+		return
+	}
+	b.failures++
+	b.consecutive++
+
+	if b.conf.ConsecutiveFailures > 0 && b.consecutive >= b.conf.ConsecutiveFailures {
+		b.open(now)
+		return
+	}
+	if b.conf.ErrorRatio > 0 && b.total >= b.conf.MinRequests && float64(b.failures)/float64(b.total) >= b.conf.ErrorRatio {
+		b.open(now)
+	}
+}
+
+// release 释放请求占用的半开探测名额，不记录结果
+func (b *CircuitBreaker) release(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation == b.generation && b.state == stateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// open 打开熔断器
+func (b *CircuitBreaker) open(now time.Time) {
+	b.setState(stateOpen)
+	b.openedAt = now
+	b.reset(now)
+}
+
+// setState 切换状态，每次切换开始新的一代
+func (b *CircuitBreaker) setState(state circuitState) {
+	b.state = state
+	b.generation++
+}
+
+// reset 清空统计
+func (b *CircuitBreaker) reset(now time.Time) {
+	b.consecutive, b.total, b.failures = 0, 0, 0
+	b.windowStart = now
+}
+
+// circuitPromise 请求结果回调，generation 为发出请求时熔断器所处的一代
+type circuitPromise struct {
+	b          *CircuitBreaker
+	generation uint64
+}
+
+// Accept 请求成功
+func (p circuitPromise) Accept() {
+	p.b.onResult(p.generation, true)
+}
+
+// Reject 请求失败
+func (p circuitPromise) Reject(_ string) {
+	p.b.onResult(p.generation, false)
+}
+
+// Release 请求被取消，不计入结果
+func (p circuitPromise) Release() {
+	p.b.release(p.generation)
+}
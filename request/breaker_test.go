@@ -0,0 +1,133 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试连续失败后熔断，半开探测成功后恢复
+func TestWithCircuitBreaker(t *testing.T) {
+	var healthy int32
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	conf := DefaultBreakerConf()
+	conf.ConsecutiveFailures = 3
+	conf.OpenTimeout = 50 * time.Millisecond
+	client := NewClient(WithCircuitBreaker(conf))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Get(ctx, server.URL, nil, nil); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("第%d次请求不应熔断", i+1)
+		}
+	}
+	if _, err := client.Get(ctx, server.URL, nil, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("连续失败后 error = %v; want ErrCircuitOpen", err)
+	}
+	if atomic.LoadInt32(&count) != 3 {
+		t.Errorf("熔断后请求不应发出，实际请求 %d 次", count)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.Get(ctx, server.URL, nil, nil); err != nil {
+		t.Fatalf("半开探测 error = %v", err)
+	}
+	if _, err := client.Get(ctx, server.URL, nil, nil); err != nil {
+		t.Errorf("探测成功后熔断器应关闭，error = %v", err)
+	}
+}
+
+// 测试按错误率熔断
+func TestCircuitBreaker_ErrorRatio(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConf{ErrorRatio: 0.5, MinRequests: 4, Window: time.Minute, OpenTimeout: time.Minute})
+	for _, success := range []bool{true, false, true, false} {
+		promise, err := b.Allow()
+		if err != nil {
+			t.Fatalf("Allow() error = %v", err)
+		}
+		if success {
+			promise.Accept()
+		} else {
+			promise.Reject("fail")
+		}
+	}
+	if _, err := b.Allow(); err == nil {
+		t.Errorf("错误率达到 50%% 后应熔断")
+	}
+}
+
+// 测试熔断器按主机隔离
+func TestWithBreaker_PerHost(t *testing.T) {
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer good.Close()
+
+	client := NewClient(WithCircuitBreaker(BreakerConf{ConsecutiveFailures: 1, OpenTimeout: time.Minute}))
+	_, _ = client.Get(context.Background(), bad.URL, nil, nil)
+	if _, err := client.Get(context.Background(), bad.URL, nil, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("bad 主机 error = %v; want ErrCircuitOpen", err)
+	}
+	if _, err := client.Get(context.Background(), good.URL, nil, nil); err != nil {
+		t.Errorf("good 主机不应受影响，error = %v", err)
+	}
+}
+
+// 测试使用 go-zero 熔断器
+func TestGoZeroBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := NewClient(WithBreaker(GoZeroBreaker))
+	if _, err := client.Get(context.Background(), server.URL, nil, nil); err != nil {
+		t.Errorf("Get() error = %v", err)
+	}
+}
+
+// 测试半开状态下取消的探测不关闭熔断器，打开前发出的请求结果不计入半开探测
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConf{ConsecutiveFailures: 1, OpenTimeout: 20 * time.Millisecond})
+	stale, _ := b.Allow()
+	promise, _ := b.Allow()
+	promise.Reject("fail")
+	time.Sleep(30 * time.Millisecond)
+
+	probe, err := b.Allow()
+	if err != nil {
+		t.Fatalf("半开 Allow() error = %v", err)
+	}
+	stale.Accept()
+	if _, err = b.Allow(); err == nil {
+		t.Fatalf("打开前发出的请求结果不应关闭熔断器")
+	}
+
+	probe.(ReleasablePromise).Release()
+	probe, err = b.Allow()
+	if err != nil {
+		t.Fatalf("取消的探测应释放名额，Allow() error = %v", err)
+	}
+	if _, err = b.Allow(); err == nil {
+		t.Fatalf("取消的探测不应关闭熔断器")
+	}
+	probe.Accept()
+	if _, err = b.Allow(); err != nil {
+		t.Errorf("探测成功后熔断器应关闭，error = %v", err)
+	}
+}
@@ -0,0 +1,362 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCacheMaxTTL 带校验信息的缓存默认最长保存时间
+const defaultCacheMaxTTL = 24 * time.Hour
+
+// defaultCacheEntries 未设置存储时内存缓存的最大数量
+const defaultCacheEntries = 1024
+
+// cacheableStatus 可以缓存的状态码
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// CacheConf 缓存配置，只缓存 get 请求，遵循 RFC 9111 共享缓存的 Cache-Control、Expires、ETag 与 Last-Modified 语义
+type CacheConf struct {
+	// Store 缓存存储，为空时使用最多 1024 条的内存缓存
+	Store CacheStore
+	// StaleWhileRevalidate 缓存过期后仍直接返回并在后台更新的时间，返回头中的 stale-while-revalidate 优先
+	StaleWhileRevalidate time.Duration
+	// StaleIfError 请求失败或返回 5xx 时仍可使用过期缓存的时间，返回头中的 stale-if-error 优先
+	StaleIfError time.Duration
+	// MaxTTL 带 ETag 或 Last-Modified 的缓存在存储中的最长保存时间，默认 24 小时
+	MaxTTL time.Duration
+}
+
+// WithCache 开启 get 请求缓存
+func WithCache(conf CacheConf) Option {
+	return func(c *Client) {
+		if conf.Store == nil {
+			conf.Store = NewMemoryCache(defaultCacheEntries)
+		}
+		if conf.MaxTTL <= 0 {
+			conf.MaxTTL = defaultCacheMaxTTL
+		}
+		c.cache = &httpCache{conf: conf, revalidating: make(map[string]struct{})}
+	}
+}
+
+// httpCache http 缓存
+type httpCache struct {
+	conf         CacheConf
+	mu           sync.Mutex
+	revalidating map[string]struct{}
+}
+
+// cacheEntry 缓存内容
+type cacheEntry struct {
+	StatusCode int               `json:"statusCode"`
+	Header     http.Header       `json:"header"`
+	Body       []byte            `json:"body"`
+	StoredAt   time.Time         `json:"storedAt"`
+	Vary       map[string]string `json:"vary,omitempty"`
+}
+
+// do 优先使用缓存处理 get 请求
+func (h *httpCache) do(ctx context.Context, c *Client, req *http.Request, timeout time.Duration) (*Response, error) {
+	reqDirectives := parseCacheControl(req.Header.Get("Cache-Control"))
+	if _, ok := reqDirectives["no-store"]; ok {
+		return c.send(ctx, req, timeout)
+	}
+
+	key := cacheKey(req.URL)
+	entry := h.load(ctx, c, key, req)
+	if entry == nil {
+		resp, err := c.send(ctx, req, timeout)
+		h.save(ctx, c, key, req, resp, err)
+		return resp, err
+	}
+
+	_, noCache := reqDirectives["no-cache"]
+	directives := parseCacheControl(entry.Header.Get("Cache-Control"))
+	mustRevalidate := hasDirective(directives, "must-revalidate", "proxy-revalidate", "s-maxage")
+	stale := entry.age(time.Now()) - entry.freshness(directives)
+	if !noCache && stale < 0 {
+		return c.checkStatus(req, entry.response(), nil)
+	}
+	if !noCache && !mustRevalidate && stale < directiveDuration(directives, "stale-while-revalidate", h.conf.StaleWhileRevalidate) {
+		resp := entry.response()
+		h.revalidateAsync(ctx, c, key, req, entry, timeout)
+		return c.checkStatus(req, resp, nil)
+	}
+
+	resp, err := h.revalidate(ctx, c, key, req, entry, timeout)
+	failed := err != nil && (resp == nil || resp.StatusCode >= http.StatusInternalServerError)
+	if failed && !mustRevalidate && stale < directiveDuration(directives, "stale-if-error", h.conf.StaleIfError) {
+		c.logger.Errorf(ctx, "缓存更新失败，使用过期缓存：%v", err)
+		return c.checkStatus(req, entry.response(), nil)
+	}
+
+	return resp, err
+}
+
+// revalidate 携带 If-None-Match / If-Modified-Since 请求，返回 304 时更新并使用缓存
+func (h *httpCache) revalidate(ctx context.Context, c *Client, key string, req *http.Request, entry *cacheEntry, timeout time.Duration) (*Response, error) {
+	req = req.Clone(ctx)
+	if etag := entry.Header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := c.send(ctx, req, timeout)
+	if resp != nil && resp.StatusCode == http.StatusNotModified {
+		for k, v := range resp.Header {
+			if k != "Content-Length" {
+				entry.Header[k] = v
+			}
+		}
+		entry.StoredAt = time.Now()
+		h.store(ctx, c, key, entry)
+		return c.checkStatus(req, entry.response(), nil)
+	}
+	h.save(ctx, c, key, req, resp, err)
+
+	return resp, err
+}
+
+// revalidateAsync 后台更新缓存，同一个缓存同时只有一个更新请求
+func (h *httpCache) revalidateAsync(ctx context.Context, c *Client, key string, req *http.Request, entry *cacheEntry, timeout time.Duration) {
+	h.mu.Lock()
+	if _, ok := h.revalidating[key]; ok {
+		h.mu.Unlock()
+		return
+	}
+	h.revalidating[key] = struct{}{}
+	h.mu.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			h.mu.Lock()
+			delete(h.revalidating, key)
+			h.mu.Unlock()
+		}()
+		if _, err := h.revalidate(ctx, c, key, req.WithContext(ctx), entry, timeout); err != nil {
+			c.logger.Errorf(ctx, "缓存后台更新失败：%v", err)
+		}
+	}()
+}
+
+// invalidate post、put 等请求成功后删除对应地址的缓存
+func (h *httpCache) invalidate(ctx context.Context, c *Client, u *url.URL) {
+	if err := h.conf.Store.Delete(ctx, cacheKey(u)); err != nil {
+		c.logger.Errorf(ctx, "缓存删除失败：%v", err)
+	}
+}
+
+// load 读取缓存，Vary 对应的请求头不一致时视为未命中
+func (h *httpCache) load(ctx context.Context, c *Client, key string, req *http.Request) *cacheEntry {
+	data, ok, err := h.conf.Store.Get(ctx, key)
+	if err != nil {
+		c.logger.Errorf(ctx, "缓存读取失败：%v", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		c.logger.Errorf(ctx, "缓存解析失败：%v", err)
+		return nil
+	}
+	for name, value := range entry.Vary {
+		if req.Header.Get(name) != value {
+			return nil
+		}
+	}
+
+	return &entry
+}
+
+// save 保存可缓存的返回结果，404 等可缓存的状态码虽然返回 StatusError 也会保存，
+// 是否可以共享按实际发出的请求判断，中间件与认证添加的 Authorization 同样生效
+func (h *httpCache) save(ctx context.Context, c *Client, key string, req *http.Request, resp *Response, err error) {
+	if resp == nil || !cacheableStatus[resp.StatusCode] {
+		return
+	}
+	var statusErr *StatusError
+	if err != nil && !errors.As(err, &statusErr) {
+		return
+	}
+	directives := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return
+	}
+	if !shareable(req, directives) || (resp.request != nil && !shareable(resp.request, directives)) {
+		return
+	}
+	vary := make(map[string]string)
+	for _, name := range strings.Split(resp.Header.Get("Vary"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "*" {
+			return
+		}
+		if name != "" {
+			vary[name] = req.Header.Get(name)
+		}
+	}
+
+	h.store(ctx, c, key, &cacheEntry{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       resp.Body,
+		StoredAt:   time.Now(),
+		Vary:       vary,
+	})
+}
+
+// store 写入缓存，存储时间为新鲜期加上可以使用过期缓存的时间，带校验信息时至少为 MaxTTL
+func (h *httpCache) store(ctx context.Context, c *Client, key string, entry *cacheEntry) {
+	directives := parseCacheControl(entry.Header.Get("Cache-Control"))
+	ttl := entry.freshness(directives) - entry.age(entry.StoredAt) + max(
+		directiveDuration(directives, "stale-while-revalidate", h.conf.StaleWhileRevalidate),
+		directiveDuration(directives, "stale-if-error", h.conf.StaleIfError),
+	)
+	if entry.Header.Get("ETag") != "" || entry.Header.Get("Last-Modified") != "" {
+		ttl = max(ttl, h.conf.MaxTTL)
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err = h.conf.Store.Set(ctx, key, data, ttl); err != nil {
+		c.logger.Errorf(ctx, "缓存写入失败：%v", err)
+	}
+}
+
+// response 将缓存转换为返回结果
+func (e *cacheEntry) response() *Response {
+	return &Response{StatusCode: e.StatusCode, Header: e.Header.Clone(), Body: e.Body, Cached: true}
+}
+
+// age 缓存的当前年龄，包含上游返回的 Age
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	var age time.Duration
+	if seconds, err := strconv.Atoi(e.Header.Get("Age")); err == nil && seconds > 0 {
+		age = time.Duration(seconds) * time.Second
+	}
+
+	return age + now.Sub(e.StoredAt)
+}
+
+// freshness 新鲜期，依次使用 s-maxage、max-age、Expires 与基于 Last-Modified 的启发式规则
+func (e *cacheEntry) freshness(directives map[string]string) time.Duration {
+	if _, ok := directives["no-cache"]; ok {
+		return 0
+	}
+	if d, ok := parseSeconds(directives["s-maxage"]); ok {
+		return d
+	}
+	if d, ok := parseSeconds(directives["max-age"]); ok {
+		return d
+	}
+
+	date := e.StoredAt
+	if t, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		date = t
+	}
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+	if t, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil && date.After(t) {
+		return date.Sub(t) / 10
+	}
+
+	return 0
+}
+
+// shareable 缓存可能被多个调用方甚至多个实例共享，按 RFC 9111 共享缓存的规则，不保存 private 的返回，
+// 携带 Authorization 的请求只有返回 public、s-maxage 或 must-revalidate 时才保存
+func shareable(req *http.Request, directives map[string]string) bool {
+	if _, ok := directives["private"]; ok {
+		return false
+	}
+	if req.Header.Get("Authorization") == "" {
+		return true
+	}
+
+	return hasDirective(directives, "public", "s-maxage", "must-revalidate")
+}
+
+// hasDirective 是否包含任一指令
+func hasDirective(directives map[string]string, names ...string) bool {
+	for _, name := range names {
+		if _, ok := directives[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cacheKey 缓存键
+func cacheKey(u *url.URL) string {
+	return http.MethodGet + " " + u.String()
+}
+
+// parseCacheControl 解析 Cache-Control，指令名统一为小写
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, val, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(val), `"`)
+	}
+
+	return directives
+}
+
+// directiveDuration 读取秒数指令，不存在时使用默认值
+func directiveDuration(directives map[string]string, name string, def time.Duration) time.Duration {
+	if d, ok := parseSeconds(directives[name]); ok {
+		return d
+	}
+
+	return def
+}
+
+// parseSeconds 解析秒数
+func parseSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
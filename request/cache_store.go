@@ -0,0 +1,230 @@
+package request
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheStore 缓存存储
+type CacheStore interface {
+	// Get 读取缓存，不存在或已过期时返回 false
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set 写入缓存，ttl 后过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除缓存
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryCache 基于 LRU 淘汰的内存缓存
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+// memoryItem 内存缓存项
+type memoryItem struct {
+	key      string
+	value    []byte
+	expireAt time.Time
+}
+
+// NewMemoryCache 初始化内存缓存，maxEntries 为最大缓存数量，0 表示不限制
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{maxEntries: maxEntries, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get 读取缓存
+func (m *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	item := elem.Value.(*memoryItem)
+	if time.Now().After(item.expireAt) {
+		m.removeElement(elem)
+		return nil, false, nil
+	}
+	m.ll.MoveToFront(elem)
+
+	return item.value, true, nil
+}
+
+// Set 写入缓存，超过最大数量时淘汰最久未使用的缓存
+func (m *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expireAt := time.Now().Add(ttl)
+	if elem, ok := m.items[key]; ok {
+		item := elem.Value.(*memoryItem)
+		item.value, item.expireAt = value, expireAt
+		m.ll.MoveToFront(elem)
+		return nil
+	}
+	m.items[key] = m.ll.PushFront(&memoryItem{key: key, value: value, expireAt: expireAt})
+	if m.maxEntries > 0 && m.ll.Len() > m.maxEntries {
+		m.removeElement(m.ll.Back())
+	}
+
+	return nil
+}
+
+// Delete 删除缓存
+func (m *MemoryCache) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.items[key]; ok {
+		m.removeElement(elem)
+	}
+
+	return nil
+}
+
+// Len 当前缓存数量
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.ll.Len()
+}
+
+// removeElement 移除缓存项
+func (m *MemoryCache) removeElement(elem *list.Element) {
+	m.ll.Remove(elem)
+	delete(m.items, elem.Value.(*memoryItem).key)
+}
+
+// DiskCache 磁盘缓存，每个缓存保存为目录下的一个文件
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache 初始化磁盘缓存，目录不存在时自动创建
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get 读取缓存，文件前 8 个字节为过期时间
+func (d *DiskCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) < 8 || time.Now().UnixNano() > int64(binary.BigEndian.Uint64(data[:8])) {
+		_ = os.Remove(d.path(key))
+		return nil, false, nil
+	}
+
+	return data[8:], true, nil
+}
+
+// Set 写入缓存，先写临时文件再重命名，避免读取到写了一半的文件
+func (d *DiskCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	data := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(data[:8], uint64(time.Now().Add(ttl).UnixNano()))
+	copy(data[8:], value)
+
+	tmp, err := os.CreateTemp(d.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), d.path(key))
+}
+
+// Delete 删除缓存
+func (d *DiskCache) Delete(_ context.Context, key string) error {
+	err := os.Remove(d.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// path 缓存文件路径
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+// RedisClient redis 客户端接口，go-zero 的 *redis.Redis 可以直接使用
+type RedisClient interface {
+	GetCtx(ctx context.Context, key string) (string, error)
+	SetexCtx(ctx context.Context, key, value string, seconds int) error
+	DelCtx(ctx context.Context, keys ...string) (int, error)
+}
+
+// RedisCache 基于 redis 的缓存，适合多个实例共享缓存
+type RedisCache struct {
+	rds    RedisClient
+	prefix string
+}
+
+// NewRedisCache 初始化 redis 缓存，prefix 为缓存键前缀
+func NewRedisCache(rds RedisClient, prefix string) *RedisCache {
+	return &RedisCache{rds: rds, prefix: prefix}
+}
+
+// Get 读取缓存
+func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.rds.GetCtx(ctx, r.key(key))
+	if err != nil || value == "" {
+		return nil, false, err
+	}
+
+	return []byte(value), true, nil
+}
+
+// Set 写入缓存，过期时间向上取整到秒
+func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	seconds := int((ttl + time.Second - 1) / time.Second)
+
+	return r.rds.SetexCtx(ctx, r.key(key), string(value), seconds)
+}
+
+// Delete 删除缓存
+func (r *RedisCache) Delete(ctx context.Context, key string) error {
+	_, err := r.rds.DelCtx(ctx, r.key(key))
+
+	return err
+}
+
+// key 拼接键前缀，原始键可能很长，使用 sha256 摘要
+func (r *RedisCache) key(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return r.prefix + hex.EncodeToString(sum[:])
+}
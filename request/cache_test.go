@@ -0,0 +1,315 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试 max-age 新鲜期内直接使用缓存
+func TestWithCache_MaxAge(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(strconv.Itoa(int(n))))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithCache(CacheConf{}))
+	for i := 0; i < 3; i++ {
+		resp, err := client.Do(context.Background(), http.MethodGet, server.URL, map[string]any{"id": 1}, nil)
+		if err != nil || string(resp.Body) != "1" || resp.Cached != (i > 0) {
+			t.Errorf("第%d次请求 body = %q, cached = %v, error = %v", i+1, resp.Body, resp.Cached, err)
+		}
+	}
+
+	// 请求头 no-cache 时强制请求
+	resp, _ := client.Do(context.Background(), http.MethodGet, server.URL, map[string]any{"id": 1}, map[string]string{"Cache-Control": "no-cache"})
+	if string(resp.Body) != "2" {
+		t.Errorf("no-cache 请求 body = %q; want 2", resp.Body)
+	}
+
+	// 不同查询参数使用不同缓存
+	resp, _ = client.Do(context.Background(), http.MethodGet, server.URL, map[string]any{"id": 2}, nil)
+	if string(resp.Body) != "3" {
+		t.Errorf("不同查询参数 body = %q; want 3", resp.Body)
+	}
+
+	// post 请求成功后删除缓存
+	_, _ = client.Post(context.Background(), server.URL+"?id=1", nil, nil)
+	resp, _ = client.Do(context.Background(), http.MethodGet, server.URL, map[string]any{"id": 1}, nil)
+	if resp.Cached {
+		t.Errorf("post 请求后缓存未删除")
+	}
+}
+
+// 测试携带 Authorization 的请求不会把返回内容共享给其他调用方
+func TestWithCache_Authorization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		default:
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithCache(CacheConf{}))
+	get := func(path, token string) *Response {
+		resp, err := client.Do(context.Background(), http.MethodGet, server.URL+path, nil, map[string]string{"Authorization": token})
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", path, err)
+		}
+		return resp
+	}
+
+	get("/", "Bearer alice")
+	if resp := get("/", "Bearer bob"); resp.Cached || string(resp.Body) != "Bearer bob" {
+		t.Errorf("携带 Authorization body = %q, cached = %v; want Bearer bob", resp.Body, resp.Cached)
+	}
+
+	get("/public", "Bearer alice")
+	if resp := get("/public", "Bearer bob"); !resp.Cached || string(resp.Body) != "Bearer alice" {
+		t.Errorf("public body = %q, cached = %v; want 使用缓存", resp.Body, resp.Cached)
+	}
+
+	get("/private", "")
+	if resp := get("/private", ""); resp.Cached {
+		t.Errorf("private 返回不应保存到缓存")
+	}
+}
+
+// 测试 ETag 与 Last-Modified 校验
+func TestWithCache_Revalidate(t *testing.T) {
+	var full, notModified int32
+	lastModified := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", lastModified)
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == lastModified {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&full, 1)
+		_, _ = w.Write([]byte("config"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithCache(CacheConf{}))
+	for i := 0; i < 3; i++ {
+		body, err := client.Get(context.Background(), server.URL, nil, nil)
+		if err != nil || string(body) != "config" {
+			t.Fatalf("第%d次请求 body = %q, error = %v", i+1, body, err)
+		}
+	}
+	if full != 1 || notModified != 2 {
+		t.Errorf("完整请求 %d 次，304 请求 %d 次; want 1, 2", full, notModified)
+	}
+}
+
+// 测试认证、中间件添加的 Authorization 在重试与对冲请求中同样阻止共享缓存
+func TestWithCache_AuthorizationMiddleware(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		if r.URL.Path == "/retry" && n%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/hedge" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	clients := map[string]*Client{
+		"retry": NewClient(WithCache(CacheConf{}), WithRetry(policy), WithAuth(BearerToken("alice"))),
+		"hedge": NewClient(WithCache(CacheConf{}), WithHedging(HedgeConf{Delay: 5 * time.Millisecond, MaxAttempts: 2}),
+			WithMiddleware(HeaderMiddleware(map[string]string{"Authorization": "Bearer alice"}))),
+	}
+	for name, client := range clients {
+		atomic.StoreInt32(&count, 0)
+		for i := 0; i < 2; i++ {
+			resp, err := client.Do(context.Background(), http.MethodGet, server.URL+"/"+name, nil, nil)
+			if err != nil || resp.Cached || string(resp.Body) != "Bearer alice" {
+				t.Errorf("%s 第%d次请求 body = %q, cached = %v, error = %v; want 不使用缓存", name, i+1, resp.Body, resp.Cached, err)
+			}
+		}
+	}
+}
+
+// 测试 404 等可缓存的状态码与 s-maxage
+func TestWithCache_StatusAndSMaxAge(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		if r.URL.Path == "/missing" {
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0, s-maxage=60")
+		_, _ = w.Write([]byte(strconv.Itoa(int(n))))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithCache(CacheConf{}))
+	for i := 0; i < 2; i++ {
+		resp, err := client.Do(context.Background(), http.MethodGet, server.URL+"/missing", nil, nil)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || resp.Cached != (i > 0) {
+			t.Errorf("404 第%d次请求 cached = %v, error = %v", i+1, resp.Cached, err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Do(context.Background(), http.MethodGet, server.URL, nil, nil)
+		if err != nil || string(resp.Body) != "2" || resp.Cached != (i > 0) {
+			t.Errorf("s-maxage 第%d次请求 body = %q, cached = %v, error = %v", i+1, resp.Body, resp.Cached, err)
+		}
+	}
+}
+
+// 测试 stale-if-error 与 stale-while-revalidate
+func TestWithCache_Stale(t *testing.T) {
+	var count, failing int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		n := atomic.AddInt32(&count, 1)
+		w.Header().Set("Cache-Control", "max-age=0")
+		_, _ = w.Write([]byte(strconv.Itoa(int(n))))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithCache(CacheConf{StaleIfError: time.Minute}))
+	_, _ = client.Get(context.Background(), server.URL, nil, nil)
+	atomic.StoreInt32(&failing, 1)
+	body, err := client.Get(context.Background(), server.URL, nil, nil)
+	if err != nil || string(body) != "1" {
+		t.Errorf("stale-if-error body = %q, error = %v; want 1", body, err)
+	}
+
+	atomic.StoreInt32(&failing, 0)
+	logger := &memoryLogger{}
+	client = NewClient(WithLogger(logger), WithCache(CacheConf{StaleWhileRevalidate: time.Minute}))
+	_, _ = client.Get(context.Background(), server.URL, nil, nil)
+	resp, err := client.Do(context.Background(), http.MethodGet, server.URL, nil, nil)
+	if err != nil || !resp.Cached || string(resp.Body) != "2" {
+		t.Errorf("stale-while-revalidate body = %q, cached = %v, error = %v", resp.Body, resp.Cached, err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&count) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&count) != 3 {
+		t.Errorf("后台更新未执行")
+	}
+}
+
+// 测试 LRU 淘汰与过期
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+	_ = cache.Set(ctx, "a", []byte("1"), time.Minute)
+	_ = cache.Set(ctx, "b", []byte("2"), time.Minute)
+	_, _, _ = cache.Get(ctx, "a")
+	_ = cache.Set(ctx, "c", []byte("3"), time.Minute)
+	if _, ok, _ := cache.Get(ctx, "b"); ok {
+		t.Errorf("最久未使用的 b 应被淘汰")
+	}
+	if v, ok, _ := cache.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("Get(a) = %q, %v", v, ok)
+	}
+
+	_ = cache.Set(ctx, "d", []byte("4"), -time.Second)
+	if _, ok, _ := cache.Get(ctx, "d"); ok {
+		t.Errorf("过期缓存不应命中")
+	}
+}
+
+// 测试磁盘缓存
+func TestDiskCache(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = cache.Set(ctx, "GET http://a", []byte("value"), time.Minute)
+	if v, ok, err := cache.Get(ctx, "GET http://a"); !ok || err != nil || string(v) != "value" {
+		t.Errorf("Get() = %q, %v, %v", v, ok, err)
+	}
+	_ = cache.Delete(ctx, "GET http://a")
+	if _, ok, _ := cache.Get(ctx, "GET http://a"); ok {
+		t.Errorf("删除后不应命中")
+	}
+}
+
+// fakeRedis 模拟 go-zero redis 客户端
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (f *fakeRedis) GetCtx(_ context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.data[key], nil
+}
+
+func (f *fakeRedis) SetexCtx(_ context.Context, key, value string, seconds int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if seconds <= 0 {
+		return fmt.Errorf("invalid expire %d", seconds)
+	}
+	f.data[key] = value
+	return nil
+}
+
+func (f *fakeRedis) DelCtx(_ context.Context, keys ...string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, key := range keys {
+		delete(f.data, key)
+	}
+	return len(keys), nil
+}
+
+// 测试 redis 缓存适配
+func TestRedisCache(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("Expires", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithCache(CacheConf{Store: NewRedisCache(&fakeRedis{data: map[string]string{}}, "http:cache:")}))
+	_, _ = client.Get(context.Background(), server.URL, nil, nil)
+	_, _ = client.Get(context.Background(), server.URL, nil, nil)
+	if atomic.LoadInt32(&count) != 1 {
+		t.Errorf("Expires 有效期内应使用缓存，实际请求 %d 次", count)
+	}
+}
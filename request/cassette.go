@@ -0,0 +1,344 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNoInteraction 回放时磁带中没有匹配的请求
+var ErrNoInteraction = errors.New("磁带中没有匹配的请求")
+
+// RecordMode 录制模式
+type RecordMode int
+
+const (
+	// RecordModeAuto 磁带文件存在时回放，不存在时录制，默认模式
+	RecordModeAuto RecordMode = iota
+	// RecordModeReplay 只回放，不会发出真实请求，用于 CI 等没有网络的环境
+	RecordModeReplay
+	// RecordModeRecord 总是发出真实请求，并覆盖磁带文件
+	RecordModeRecord
+)
+
+// MatchField 回放时参与匹配的请求内容
+type MatchField int
+
+const (
+	// MatchMethod 匹配请求方法
+	MatchMethod MatchField = 1 << iota
+	// MatchURL 匹配请求地址，查询参数顺序不影响匹配
+	MatchURL
+	// MatchBody 匹配请求体
+	MatchBody
+)
+
+// CassetteConf 录制与回放配置
+type CassetteConf struct {
+	// Path 磁带文件路径，扩展名为 .json 时使用 json 格式，否则使用 yaml 格式
+	Path string
+	// Mode 录制模式
+	Mode RecordMode
+	// Match 回放时参与匹配的请求内容，默认匹配请求方法与地址
+	Match MatchField
+	// MatchHeaders 回放时额外参与匹配的请求头
+	MatchHeaders []string
+	// Redactor 保存前对请求头、返回头、查询参数与 json 内容脱敏，为空时只脱敏 Authorization、Cookie 等默认请求头
+	Redactor *Redactor
+	// Replacements 保存前将敏感内容替换为占位符，例如 {"真实token": "<TOKEN>"}，作用于地址、请求头与内容
+	Replacements map[string]string
+	// Transport 录制时发出真实请求的 RoundTripper，默认为 http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// Interaction 一次请求与返回
+type Interaction struct {
+	Request  CassetteRequest  `json:"request" yaml:"request"`
+	Response CassetteResponse `json:"response" yaml:"response"`
+}
+
+// CassetteRequest 录制的请求
+type CassetteRequest struct {
+	Method string      `json:"method" yaml:"method"`
+	URL    string      `json:"url" yaml:"url"`
+	Header http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body   string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// CassetteResponse 录制的返回
+type CassetteResponse struct {
+	StatusCode int         `json:"statusCode" yaml:"statusCode"`
+	Header     http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body       string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// cassette 磁带文件内容
+type cassette struct {
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Recorder 录制与回放请求的 RoundTripper，配合 WithTransport 使用，使测试不依赖网络
+type Recorder struct {
+	conf      CassetteConf
+	recording bool
+	mu        sync.Mutex
+	cassette  cassette
+	used      map[*Interaction]bool
+}
+
+// NewRecorder 初始化录制器，回放模式下加载磁带文件
+func NewRecorder(conf CassetteConf) (*Recorder, error) {
+	if conf.Match == 0 {
+		conf.Match = MatchMethod | MatchURL
+	}
+	if conf.Redactor == nil {
+		conf.Redactor = &Redactor{}
+	}
+	if conf.Transport == nil {
+		conf.Transport = http.DefaultTransport
+	}
+	r := &Recorder{conf: conf, used: make(map[*Interaction]bool)}
+
+	data, err := os.ReadFile(conf.Path)
+	switch {
+	case conf.Mode == RecordModeRecord, conf.Mode == RecordModeAuto && errors.Is(err, os.ErrNotExist):
+		r.recording = true
+		return r, nil
+	case err != nil:
+		return nil, err
+	}
+	if r.isJSON() {
+		err = json.Unmarshal(data, &r.cassette)
+	} else {
+		err = yaml.Unmarshal(data, &r.cassette)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("磁带文件解析失败：%w", err)
+	}
+
+	return r, nil
+}
+
+// Recording 是否处于录制状态
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// Interactions 已录制或加载的请求
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interactions := make([]Interaction, len(r.cassette.Interactions))
+	for i, interaction := range r.cassette.Interactions {
+		interactions[i] = *interaction
+	}
+
+	return interactions
+}
+
+// RoundTrip 实现 http.RoundTripper，录制时发出真实请求并保存，回放时返回磁带中匹配的返回
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := r.request(req, body)
+	if r.recording {
+		return r.record(req, recorded)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interaction := r.find(recorded)
+	if interaction == nil {
+		return nil, fmt.Errorf("%w：%s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+	}
+
+	return interaction.Response.response(req), nil
+}
+
+// record 发出真实请求并将脱敏后的请求与返回写入磁带文件
+func (r *Recorder) record(req *http.Request, recorded CassetteRequest) (*http.Response, error) {
+	resp, err := r.conf.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: recorded,
+		Response: CassetteResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.header(resp.Header),
+			Body:       r.replace(r.conf.Redactor.body(respBody)),
+		},
+	})
+	if err = r.save(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// save 写入磁带文件
+func (r *Recorder) save() error {
+	var (
+		data []byte
+		err  error
+	)
+	if r.isJSON() {
+		data, err = json.MarshalIndent(r.cassette, "", "  ")
+	} else {
+		data, err = yaml.Marshal(r.cassette)
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.conf.Path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.conf.Path, data, 0o644)
+}
+
+// find 查找匹配的请求，优先使用尚未回放过的记录，全部回放过时重复使用最后一条匹配的记录
+func (r *Recorder) find(req CassetteRequest) *Interaction {
+	var last *Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if !r.match(req, interaction.Request) {
+			continue
+		}
+		if !r.used[interaction] {
+			r.used[interaction] = true
+			return interaction
+		}
+		last = interaction
+	}
+
+	return last
+}
+
+// match 判断请求是否与记录匹配
+func (r *Recorder) match(req, recorded CassetteRequest) bool {
+	if r.conf.Match&MatchMethod != 0 && req.Method != recorded.Method {
+		return false
+	}
+	if r.conf.Match&MatchURL != 0 && normalizeURL(req.URL) != normalizeURL(recorded.URL) {
+		return false
+	}
+	if r.conf.Match&MatchBody != 0 && req.Body != recorded.Body {
+		return false
+	}
+	for _, name := range r.conf.MatchHeaders {
+		if req.Header.Get(name) != recorded.Header.Get(name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// request 将请求转换为脱敏后的记录，回放时使用相同规则处理后再匹配
+func (r *Recorder) request(req *http.Request, body []byte) CassetteRequest {
+	recorded := CassetteRequest{
+		Method: req.Method,
+		URL:    r.replace(r.conf.Redactor.url(req.URL.String())),
+		Header: r.header(req.Header),
+	}
+	if len(body) > 0 {
+		recorded.Body = r.replace(r.conf.Redactor.body(body))
+	}
+
+	return recorded
+}
+
+// header 请求头与返回头脱敏
+func (r *Recorder) header(header http.Header) http.Header {
+	names := r.conf.Redactor.Headers
+	if len(names) == 0 {
+		names = defaultRedactHeaders
+	}
+
+	redacted := make(http.Header, len(header))
+	for k, vs := range header {
+		values := make([]string, len(vs))
+		for i, v := range vs {
+			values[i] = r.replace(v)
+		}
+		for _, name := range names {
+			if strings.EqualFold(k, name) {
+				values = []string{redactedValue}
+				break
+			}
+		}
+		redacted[k] = values
+	}
+
+	return redacted
+}
+
+// replace 将敏感内容替换为占位符
+func (r *Recorder) replace(s string) string {
+	for secret, placeholder := range r.conf.Replacements {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, placeholder)
+		}
+	}
+
+	return s
+}
+
+// isJSON 磁带文件是否为 json 格式
+func (r *Recorder) isJSON() bool {
+	return strings.EqualFold(filepath.Ext(r.conf.Path), ".json")
+}
+
+// response 生成回放的返回
+func (c CassetteResponse) response(req *http.Request) *http.Response {
+	// 脱敏后内容长度可能变化，以实际内容为准
+	header := c.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Del("Content-Length")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// normalizeURL 查询参数排序后的地址
+func normalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.RawQuery = u.Query().Encode()
+
+	return u.String()
+}
@@ -0,0 +1,109 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试录制后回放，回放时不发出真实请求
+func TestRecorder(t *testing.T) {
+	for _, name := range []string{"cassette.yaml", "cassette.json"} {
+		t.Run(name, func(t *testing.T) {
+			var count int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				count++
+				w.Header().Set("Set-Cookie", "session=abc")
+				_, _ = w.Write([]byte(`{"name":"tom","token":"resp-token","page":"` + r.URL.Query().Get("page") + `"}`))
+			}))
+			defer server.Close()
+
+			path := filepath.Join(t.TempDir(), "testdata", name)
+			conf := CassetteConf{
+				Path:         path,
+				Redactor:     &Redactor{Fields: []string{"token"}},
+				Replacements: map[string]string{"secret-key": "<API_KEY>"},
+			}
+			recorder, err := NewRecorder(conf)
+			if err != nil || !recorder.Recording() {
+				t.Fatalf("NewRecorder() = %v, %v; want recording", recorder.Recording(), err)
+			}
+			client := NewClient(WithTransport(recorder))
+			header := map[string]string{"Authorization": "Bearer abc", "X-Api-Key": "secret-key"}
+			for _, page := range []string{"1", "2"} {
+				_, _ = client.Get(context.Background(), server.URL, map[string]any{"page": page, "key": "secret-key"}, header)
+			}
+
+			data, _ := os.ReadFile(path)
+			for _, secret := range []string{"Bearer abc", "secret-key", "resp-token", "session=abc"} {
+				if strings.Contains(string(data), secret) {
+					t.Errorf("磁带文件中包含敏感内容 %s", secret)
+				}
+			}
+
+			server.Close()
+			recorder, err = NewRecorder(conf)
+			if err != nil || recorder.Recording() || len(recorder.Interactions()) != 2 {
+				t.Fatalf("NewRecorder() 回放模式加载失败：%v", err)
+			}
+			client = NewClient(WithTransport(recorder))
+			body, err := client.Get(context.Background(), server.URL, map[string]any{"key": "secret-key", "page": "2"}, header)
+			if err != nil || !strings.Contains(string(body), `"page":"2"`) {
+				t.Errorf("回放 Get() = %s, %v", body, err)
+			}
+			if _, err = client.Get(context.Background(), server.URL, map[string]any{"page": "3"}, nil); !errors.Is(err, ErrNoInteraction) {
+				t.Errorf("没有匹配的请求 error = %v; want ErrNoInteraction", err)
+			}
+			if count != 2 {
+				t.Errorf("实际请求 %d 次; want 2", count)
+			}
+		})
+	}
+}
+
+// 测试按请求体与请求头匹配
+func TestRecorder_Match(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	_ = os.WriteFile(path, []byte(`interactions:
+- request:
+    method: POST
+    url: http://example.com/orders
+    header:
+      X-Tenant: [a]
+    body: '{"id":1}'
+  response:
+    statusCode: 201
+    body: created-a
+- request:
+    method: POST
+    url: http://example.com/orders
+    header:
+      X-Tenant: [b]
+    body: '{"id":1}'
+  response:
+    statusCode: 201
+    body: created-b
+`), 0o644)
+
+	recorder, err := NewRecorder(CassetteConf{Path: path, Mode: RecordModeReplay, Match: MatchMethod | MatchURL | MatchBody, MatchHeaders: []string{"X-Tenant"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(WithTransport(recorder), WithHeader(map[string]string{"Content-Type": ApplicationJson}))
+	body, err := client.Post(context.Background(), "http://example.com/orders", map[string]any{"id": 1}, map[string]string{"X-Tenant": "b"})
+	if err != nil || string(body) != "created-b" {
+		t.Errorf("Post() = %s, %v; want created-b", body, err)
+	}
+	if _, err = client.Post(context.Background(), "http://example.com/orders", map[string]any{"id": 2}, map[string]string{"X-Tenant": "b"}); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("请求体不同 error = %v; want ErrNoInteraction", err)
+	}
+
+	if _, err = NewRecorder(CassetteConf{Path: filepath.Join(t.TempDir(), "missing.yaml"), Mode: RecordModeReplay}); err == nil {
+		t.Errorf("回放模式下磁带文件不存在应返回错误")
+	}
+}
@@ -0,0 +1,544 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/zeromicro/go-zero/core/logc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Logger 请求日志接口，默认使用 go-zero 的 logc 输出
+type Logger interface {
+	Infof(ctx context.Context, format string, v ...any)
+	Errorf(ctx context.Context, format string, v ...any)
+}
+
+// logcLogger 基于 logc 的默认日志实现
+type logcLogger struct{}
+
+func (logcLogger) Infof(ctx context.Context, format string, v ...any) {
+	logc.Infof(ctx, format, v...)
+}
+
+func (logcLogger) Errorf(ctx context.Context, format string, v ...any) {
+	logc.Errorf(ctx, format, v...)
+}
+
+// Response 请求返回结果
+type Response struct {
+	// StatusCode http状态码
+	StatusCode int
+	// Header 返回头
+	Header http.Header
+	// Body 返回内容
+	Body []byte
+	// Attempts 实际请求次数
+	Attempts int
+	// Cached 返回结果是否来自缓存
+	Cached bool
+	// Timing 最后一次请求的各阶段耗时，开启 WithHTTPTrace 时才有值
+	Timing *Timing
+	// request 经过中间件、签名与认证后实际发出的请求
+	request *http.Request
+}
+
+// Client 可复用的http客户端，不同的上游服务可以各自持有一个独立配置的 Client
+type Client struct {
+	baseURL        string
+	header         map[string]string
+	timeout        time.Duration
+	transport      http.RoundTripper
+	logger         Logger
+	maxBodySize    int64
+	retry          *RetryPolicy
+	acceptStatus   []StatusRange
+	tracerProvider trace.TracerProvider
+	logLevel       LogLevel
+	redactor       *Redactor
+	middlewares    []Middleware
+	handler        Handler
+	breakers       *breakerGroup
+	limiters       *limiterGroup
+	cache          *httpCache
+	dedup          *dedupGroup
+	hedging        *HedgeConf
+	auth           Authenticator
+	signer         Signer
+	curlOnError    bool
+	httpTrace      bool
+	expectTypes    []string
+	decompress     *decompressor
+	compress       *CompressConf
+	httpClient     *http.Client
+}
+
+// Option 客户端配置项
+type Option func(c *Client)
+
+// WithBaseURL 设置基础地址，请求地址为相对路径时会拼接在基础地址之后
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHeader 设置默认请求头，单次请求传入的同名请求头优先
+func WithHeader(header map[string]string) Option {
+	return func(c *Client) {
+		for k, v := range header {
+			c.header[k] = v
+		}
+	}
+}
+
+// WithTimeout 设置默认超时时间，0 表示不超时
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithTransport 设置自定义 RoundTripper
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+// WithLogger 设置日志输出
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithMaxBodySize 设置返回内容的最大字节数，超过时返回 ErrBodyTooLarge，0 表示不限制
+func WithMaxBodySize(size int64) Option {
+	return func(c *Client) {
+		c.maxBodySize = size
+	}
+}
+
+// NewClient 初始化客户端
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		header:    make(map[string]string),
+		transport: http.DefaultTransport,
+		logger:    logcLogger{},
+		redactor:  &Redactor{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = &http.Client{Transport: c.transport}
+	handler := Handler(c.httpClient.Do)
+	if c.breakers != nil {
+		handler = c.breakers.middleware(handler)
+	}
+	if c.limiters != nil {
+		handler = c.limiters.middleware(handler)
+	}
+	if c.signer != nil {
+		handler = signMiddleware(c.signer)(handler)
+	}
+	if c.auth != nil {
+		handler = authMiddleware(c.auth)(handler)
+	}
+	c.handler = chain(handler, c.middlewares)
+
+	return c
+}
+
+// Get 发起get请求，reqData 作为查询参数
+func (c *Client) Get(ctx context.Context, reqUrl string, reqData any, header map[string]string) ([]byte, error) {
+	return c.body(c.Do(ctx, http.MethodGet, reqUrl, reqData, header))
+}
+
+// Post 发起post请求
+func (c *Client) Post(ctx context.Context, reqUrl string, reqData any, header map[string]string) ([]byte, error) {
+	return c.body(c.Do(ctx, http.MethodPost, reqUrl, reqData, header))
+}
+
+// Put 发起put请求
+func (c *Client) Put(ctx context.Context, reqUrl string, reqData any, header map[string]string) ([]byte, error) {
+	return c.body(c.Do(ctx, http.MethodPut, reqUrl, reqData, header))
+}
+
+// Patch 发起patch请求
+func (c *Client) Patch(ctx context.Context, reqUrl string, reqData any, header map[string]string) ([]byte, error) {
+	return c.body(c.Do(ctx, http.MethodPatch, reqUrl, reqData, header))
+}
+
+// Delete 发起delete请求
+func (c *Client) Delete(ctx context.Context, reqUrl string, reqData any, header map[string]string) ([]byte, error) {
+	return c.body(c.Do(ctx, http.MethodDelete, reqUrl, reqData, header))
+}
+
+// Head 发起head请求，不读取返回内容
+func (c *Client) Head(ctx context.Context, reqUrl string, reqData any, header map[string]string) (*Response, error) {
+	return c.Do(ctx, http.MethodHead, reqUrl, reqData, header)
+}
+
+// Options 发起options请求，可通过返回头中的 Allow 获取支持的请求方法
+func (c *Client) Options(ctx context.Context, reqUrl string, reqData any, header map[string]string) (*Response, error) {
+	return c.Do(ctx, http.MethodOptions, reqUrl, reqData, header)
+}
+
+// Do 使用客户端的默认超时时间发起请求，状态码不在可接受范围内时同时返回 Response 与 *StatusError
+func (c *Client) Do(ctx context.Context, method, reqUrl string, reqData any, header map[string]string) (*Response, error) {
+	return c.do(ctx, method, reqUrl, reqData, header, c.timeout)
+}
+
+// body 取出返回内容
+func (c *Client) body(resp *Response, err error) ([]byte, error) {
+	if resp == nil {
+		return nil, err
+	}
+
+	return resp.Body, err
+}
+
+// do 发起请求并记录日志
+func (c *Client) do(ctx context.Context, method, reqUrl string, reqData any, header map[string]string, timeout time.Duration) (*Response, error) {
+	start := time.Now()
+	method = strings.ToUpper(method)
+	ctx, span := c.startSpan(ctx, method, reqUrl)
+	reqUrl = c.resolveURL(reqUrl)
+	header = c.mergeHeader(header)
+
+	var (
+		resp *Response
+		err  error
+	)
+	defer func() {
+		if resp != nil {
+			endSpan(span, resp.StatusCode, len(resp.Body), resp.Attempts, err)
+		} else {
+			endSpan(span, 0, 0, 0, err)
+		}
+	}()
+	if !hasBody(method) {
+		resp, err = c.queryRequest(ctx, method, reqUrl, reqData, header, timeout)
+	} else {
+		// 携带请求体的请求默认为 form 类型
+		if _, ok := header["Content-Type"]; !ok {
+			header["Content-Type"] = ApplicationForm
+		}
+		resp, err = c.bodyRequest(ctx, method, reqUrl, reqData, header, timeout)
+	}
+
+	c.logRequest(ctx, method, reqUrl, reqData, header, timeout, resp, err, time.Since(start))
+
+	return resp, err
+}
+
+// logRequest 按日志级别记录脱敏后的请求日志
+func (c *Client) logRequest(ctx context.Context, method, reqUrl string, reqData any, header map[string]string, timeout time.Duration, resp *Response, err error, duration time.Duration) {
+	switch c.logLevel {
+	case LogLevelOff:
+		return
+	case LogLevelSummary:
+		var statusCode int
+		if resp != nil {
+			statusCode = resp.StatusCode
+		}
+		elapsed := duration.String()
+		if resp != nil && resp.Timing != nil {
+			elapsed += "（" + resp.Timing.String() + "）"
+		}
+		if err != nil {
+			c.logger.Errorf(ctx, "接口请求失败，%s %s，状态码：%d，耗时：%s，返回错误：%s", method, c.redactor.url(reqUrl), statusCode, elapsed, c.redactor.error(err))
+		} else {
+			c.logger.Infof(ctx, "接口请求成功，%s %s，状态码：%d，耗时：%s", method, c.redactor.url(reqUrl), statusCode, elapsed)
+		}
+		return
+	}
+
+	var params = map[string]any{
+		"url":     c.redactor.url(reqUrl),
+		"method":  method,
+		"data":    c.redactor.data(reqData),
+		"header":  c.redactor.header(header),
+		"timeout": timeout,
+	}
+	if resp != nil && resp.Timing != nil {
+		params["timing"] = resp.Timing.String()
+	}
+	if err != nil {
+		c.logger.Errorf(ctx, "接口请求失败，请求内容：%+v，返回错误：%s", params, c.redactor.error(err))
+	} else {
+		c.logger.Infof(ctx, "接口请求成功，请求内容：%+v，返回数据：%+v", params, c.redactor.body(resp.Body))
+	}
+}
+
+// resolveURL 相对路径拼接基础地址
+func (c *Client) resolveURL(reqUrl string) string {
+	if c.baseURL == "" || strings.HasPrefix(reqUrl, "http://") || strings.HasPrefix(reqUrl, "https://") {
+		return reqUrl
+	}
+	if reqUrl == "" {
+		return c.baseURL
+	}
+
+	return strings.TrimRight(c.baseURL, "/") + "/" + strings.TrimLeft(reqUrl, "/")
+}
+
+// mergeHeader 合并默认请求头与单次请求头
+func (c *Client) mergeHeader(header map[string]string) map[string]string {
+	merged := make(map[string]string, len(c.header)+len(header))
+	for k, v := range c.header {
+		merged[k] = v
+	}
+	for k, v := range header {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// hasBody 判断请求方法是否携带请求体，get、head、options 请求的参数放在查询参数中
+func hasBody(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// queryRequest 参数放在查询参数中的请求
+func (c *Client) queryRequest(ctx context.Context, method, reqUrl string, reqData any, header map[string]string, timeout time.Duration) (*Response, error) {
+	req, err := c.newRequest(ctx, method, reqUrl, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	query := req.URL.Query()
+	if err = encodeValues(query, reqData, "query"); err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = query.Encode()
+	if method == http.MethodGet {
+		return c.sendDedup(ctx, req, timeout)
+	}
+
+	return c.send(ctx, req, timeout)
+}
+
+// bodyRequest 携带请求体的请求，reqData 为 *MultipartForm 时使用 multipart 编码，否则根据 Content-Type 选择 json 或 form 编码
+// 开启 WithRequestCompression 时 json 与 form 请求体超过阈值后压缩
+func (c *Client) bodyRequest(ctx context.Context, method, reqUrl string, reqData any, header map[string]string, timeout time.Duration) (*Response, error) {
+	var data io.Reader
+	if form, ok := reqData.(*MultipartForm); ok {
+		var body io.ReadCloser
+		body, header["Content-Type"] = form.reader(ctx)
+		defer body.Close()
+		data = body
+	} else if strings.HasPrefix(header["Content-Type"], ApplicationJson) && c.compress != nil && c.compress.Stream {
+		body, err := c.compress.streamJSON(reqData, header)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		data = body
+	} else if strings.HasPrefix(header["Content-Type"], ApplicationJson) {
+		jsonData, err := json.Marshal(reqData)
+		if err != nil {
+			return nil, err
+		}
+
+		if data, err = c.compress.body(jsonData, header); err != nil {
+			return nil, err
+		}
+	} else {
+		params := url.Values{}
+		if err := encodeValues(params, reqData, "form"); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if data, err = c.compress.body([]byte(params.Encode()), header); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := c.newRequest(ctx, method, reqUrl, data, header)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(ctx, req, timeout)
+	if err == nil && c.cache != nil {
+		c.cache.invalidate(ctx, c, req.URL)
+	}
+
+	return resp, err
+}
+
+// newRequest 初始化请求，注入链路追踪信息并设置请求头
+func (c *Client) newRequest(ctx context.Context, method, reqUrl string, body io.Reader, header map[string]string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, reqUrl, body)
+	if err != nil {
+		c.logger.Errorf(ctx, "%s请求初始化失败: %s", strings.ToLower(method), err)
+		return nil, err
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	for hk, hv := range header {
+		req.Header.Set(hk, hv)
+	}
+
+	return req, nil
+}
+
+// send 发送请求并读取返回内容，配置了重试策略时按策略重试，timeout 作用于每一次请求
+func (c *Client) send(ctx context.Context, req *http.Request, timeout time.Duration) (*Response, error) {
+	maxAttempts := c.retry.maxAttempts(req.Method)
+	for attempt := 1; ; attempt++ {
+		resp, err := c.roundTrip(ctx, req, timeout)
+		var (
+			statusCode int
+			header     http.Header
+		)
+		if resp != nil {
+			resp.Attempts = attempt
+			statusCode, header = resp.StatusCode, resp.Header
+		}
+		if attempt >= maxAttempts || ctx.Err() != nil || !c.retry.shouldRetry(statusCode, err) {
+			return c.finish(ctx, req, resp, err)
+		}
+		// 请求体无法重新读取时不能重试
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return c.finish(ctx, req, resp, err)
+		}
+
+		delay := c.retry.delay(attempt, statusCode, header)
+		if err != nil {
+			c.logger.Errorf(ctx, "第%d次请求失败，%s后重试: %s", attempt, delay, err)
+		} else {
+			c.logger.Errorf(ctx, "第%d次请求返回状态码%d，%s后重试", attempt, resp.StatusCode, delay)
+		}
+		if err = sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		req = req.Clone(ctx)
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// finish 检查状态码，请求失败时按配置记录 curl 命令
+func (c *Client) finish(ctx context.Context, req *http.Request, resp *Response, err error) (*Response, error) {
+	resp, err = c.checkStatus(req, resp, err)
+	if err != nil && c.curlOnError {
+		c.logger.Errorf(ctx, "请求失败，复现命令：%s", CurlCommand(req, c.redactor))
+	}
+
+	return resp, err
+}
+
+// checkStatus 状态码不在可接受范围内时返回 StatusError，Content-Type 不符合预期时返回 ErrUnexpectedContentType，同时保留返回结果
+func (c *Client) checkStatus(req *http.Request, resp *Response, err error) (*Response, error) {
+	if err != nil {
+		return resp, err
+	}
+	if !c.accept(resp.StatusCode) {
+		return resp, newStatusError(req, resp)
+	}
+
+	return resp, c.checkContentType(resp)
+}
+
+// roundTrip 发送单次请求并读取返回内容
+func (c *Client) roundTrip(ctx context.Context, req *http.Request, timeout time.Duration) (*Response, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+	if c.hedging != nil && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+		return c.hedge(ctx, req)
+	}
+
+	return c.exchange(ctx, req)
+}
+
+// exchange 通过 handler 发送请求并读取返回内容
+func (c *Client) exchange(ctx context.Context, req *http.Request) (*Response, error) {
+	traceCtx, timer := c.withPhaseTimer(req.Context())
+	req = req.WithContext(traceCtx)
+	// 只有这里会解压返回内容，下载与订阅等流式读取的请求由 http.Transport 透明解压
+	if c.decompress != nil && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", c.decompress.acceptEncoding())
+	}
+
+	method := strings.ToLower(req.Method)
+	resp, err := c.handler(req)
+	if err != nil {
+		timer.done(ctx)
+		c.logger.Errorf(ctx, "%s请求失败: %s", method, err)
+		return nil, err
+	}
+	defer func() {
+		err = resp.Body.Close()
+		if err != nil {
+			c.logger.Errorf(ctx, "http.body资源关闭失败: %s", err)
+		}
+	}()
+
+	// head 请求没有返回内容
+	if req.Method == http.MethodHead {
+		return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Timing: timer.done(ctx), request: sentRequest(req, resp)}, nil
+	}
+
+	var reader io.Reader = resp.Body
+	limit := c.maxBodySize
+	if c.decompress != nil {
+		if reader, err = c.decompress.decode(resp); err != nil {
+			timer.done(ctx)
+			c.logger.Errorf(ctx, "%s请求返回数据解压失败: %s", method, err)
+			return nil, err
+		}
+		limit = c.decompress.limit(c.maxBodySize)
+	}
+	if limit > 0 {
+		reader = io.LimitReader(reader, limit+1)
+	}
+	respBody, err := io.ReadAll(reader)
+	timing := timer.done(ctx)
+	if err != nil {
+		c.logger.Errorf(ctx, "%s请求返回数据读取失败: %s", method, err)
+		return nil, err
+	}
+	if limit > 0 && int64(len(respBody)) > limit {
+		return nil, fmt.Errorf("%w %d 字节", ErrBodyTooLarge, limit)
+	}
+
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBody,
+		Timing:     timing,
+		request:    sentRequest(req, resp),
+	}, nil
+}
+
+// sentRequest 实际发出的请求，自定义 Transport 或中间件未设置 resp.Request 时使用交给 handler 的请求
+func sentRequest(req *http.Request, resp *http.Response) *http.Request {
+	if resp.Request != nil {
+		return resp.Request
+	}
+
+	return req
+}
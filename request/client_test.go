@@ -0,0 +1,158 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 测试客户端默认配置与单次请求参数的合并
+func TestClient_Get(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"path":   r.URL.Path,
+			"name":   r.URL.Query().Get("name"),
+			"token":  r.Header.Get("X-Token"),
+			"trace":  r.Header.Get("X-Trace"),
+			"method": r.Method,
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL+"/api/"),
+		WithHeader(map[string]string{"X-Token": "default", "X-Trace": "default"}),
+		WithTimeout(time.Second),
+	)
+	body, err := client.Get(context.Background(), "/users", map[string]any{"name": "tom"}, map[string]string{"X-Trace": "abc"})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	var got map[string]string
+	if err = json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("返回数据解析失败: %v", err)
+	}
+	want := map[string]string{"path": "/api/users", "name": "tom", "token": "default", "trace": "abc", "method": http.MethodGet}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Get() %s = %q; want %q", k, got[k], v)
+		}
+	}
+}
+
+// 测试 put/patch/delete 请求使用正确的请求方法并携带请求体
+func TestClient_BodyMethods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.Method + " " + r.Header.Get("Content-Type") + " " + string(b)))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	ctx := context.Background()
+	data := map[string]any{"id": 1}
+	jsonHeader := map[string]string{"Content-Type": ApplicationJson}
+
+	tests := []struct {
+		name string
+		call func() ([]byte, error)
+		want string
+	}{
+		{name: "put", call: func() ([]byte, error) { return client.Put(ctx, "/", data, jsonHeader) }, want: `PUT application/json {"id":1}`},
+		{name: "patch", call: func() ([]byte, error) { return client.Patch(ctx, "/", data, nil) }, want: "PATCH " + ApplicationForm + " id=1"},
+		{name: "delete", call: func() ([]byte, error) { return client.Delete(ctx, "/", data, jsonHeader) }, want: `DELETE application/json {"id":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := tt.call()
+			if err != nil {
+				t.Fatalf("%s error = %v", tt.name, err)
+			}
+			if string(body) != tt.want {
+				t.Errorf("%s = %q; want %q", tt.name, body, tt.want)
+			}
+		})
+	}
+}
+
+// 测试返回内容超过最大限制
+func TestClient_MaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithMaxBodySize(5))
+	if _, err := client.Get(context.Background(), server.URL, nil, nil); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Get() error = %v; want ErrBodyTooLarge", err)
+	}
+}
+
+// 测试 DoRequest 通过默认客户端发起请求
+func TestDoRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		_, _ = w.Write([]byte(r.Method + " " + r.PostForm.Get("msg")))
+	}))
+	defer server.Close()
+
+	body, err := DoRequest(context.Background(), server.URL, http.MethodPost, map[string]any{"msg": "hello"}, nil, time.Second)
+	if err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	if string(body) != "POST hello" {
+		t.Errorf("DoRequest() = %q; want %q", body, "POST hello")
+	}
+}
+
+// 测试 head/options 请求
+func TestClient_HeadOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Query", r.URL.Query().Get("id"))
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Allow", "GET, POST, OPTIONS")
+		}
+		_, _ = w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	resp, err := client.Head(context.Background(), "/", map[string]any{"id": 1}, nil)
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+	if resp.Header.Get("X-Method") != http.MethodHead || resp.Header.Get("X-Query") != "1" || len(resp.Body) != 0 {
+		t.Errorf("Head() = %+v", resp)
+	}
+
+	resp, err = client.Options(context.Background(), "/", nil, nil)
+	if err != nil {
+		t.Fatalf("Options() error = %v", err)
+	}
+	if resp.Header.Get("Allow") != "GET, POST, OPTIONS" {
+		t.Errorf("Options() Allow = %q", resp.Header.Get("Allow"))
+	}
+}
+
+// 测试 DoRequest 的请求方法不区分大小写，且不再把非 get 请求都当作 post
+func TestDoRequest_Methods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.Method + " " + string(b)))
+	}))
+	defer server.Close()
+
+	for method, want := range map[string]string{"put": "PUT a=1", http.MethodPatch: "PATCH a=1", http.MethodDelete: "DELETE a=1"} {
+		body, err := DoRequest(context.Background(), server.URL, method, map[string]any{"a": 1}, nil, time.Second)
+		if err != nil || string(body) != want {
+			t.Errorf("DoRequest(%s) = %q, %v; want %q", method, body, err, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package request
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+)
+
+// defaultCompressThreshold 默认压缩阈值
+const defaultCompressThreshold = 1024
+
+// CompressConf 请求体压缩配置
+type CompressConf struct {
+	// Encoding 压缩格式，作为 Content-Encoding 请求头，默认 gzip，内置 gzip 与 zstd，br 等其他格式需要设置 Encoder
+	Encoding string
+	// Threshold 请求体超过该字节数时才压缩，默认 1KB
+	Threshold int
+	// Level 压缩级别，gzip 为 gzip.BestSpeed~gzip.BestCompression，zstd 为 1~22，默认为各自的默认级别
+	Level int
+	// Encoder 自定义压缩实现，Encoding 不是内置格式时必须设置，否则需要压缩的请求返回错误，
+	// 例如使用 github.com/andybalholm/brotli 的 brotli.NewWriter 支持 br
+	Encoder func(w io.Writer) (io.WriteCloser, error)
+	// Stream json 请求体边编码边压缩，不在内存中生成完整的 json，适合批量上报等大请求体
+	// 流式请求体总是压缩（此时无法预知大小），且请求失败时不会重试
+	Stream bool
+}
+
+// WithRequestCompression 开启 json 与 form 请求体压缩，multipart 请求不压缩，内置 gzip 与 zstd，其他格式通过 Encoder 设置
+func WithRequestCompression(conf CompressConf) Option {
+	return func(c *Client) {
+		if conf.Encoding == "" {
+			conf.Encoding = "gzip"
+		}
+		if conf.Threshold <= 0 {
+			conf.Threshold = defaultCompressThreshold
+		}
+		if conf.Encoder == nil {
+			conf.Encoder = builtinEncoder(conf.Encoding, conf.Level)
+		}
+		c.compress = &conf
+	}
+}
+
+// builtinEncoder 内置的压缩实现，不是内置格式时返回 nil
+func builtinEncoder(encoding string, level int) func(w io.Writer) (io.WriteCloser, error) {
+	switch encoding {
+	case "gzip":
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
+		}
+	case "zstd":
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(zstdMaxWindow)}
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w, opts...)
+		}
+	default:
+		return nil
+	}
+}
+
+// body 请求体超过阈值时压缩并设置 Content-Encoding
+func (conf *CompressConf) body(data []byte, header map[string]string) (io.Reader, error) {
+	if conf == nil || len(data) <= conf.Threshold {
+		return bytes.NewBuffer(data), nil
+	}
+	if conf.Encoder == nil {
+		return nil, fmt.Errorf("没有设置 %s 压缩实现", conf.Encoding)
+	}
+
+	var buf bytes.Buffer
+	w, err := conf.Encoder(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	header["Content-Encoding"] = conf.Encoding
+
+	return &buf, nil
+}
+
+// streamJSON 在后台边编码边压缩，读取方关闭后编码中止
+func (conf *CompressConf) streamJSON(reqData any, header map[string]string) (io.ReadCloser, error) {
+	if conf.Encoder == nil {
+		return nil, fmt.Errorf("没有设置 %s 压缩实现", conf.Encoding)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		w, err := conf.Encoder(pw)
+		if err == nil {
+			err = json.NewEncoder(w).Encode(reqData)
+			if closeErr := w.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	header["Content-Encoding"] = conf.Encoding
+
+	return pr, nil
+}
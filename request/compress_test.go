@@ -0,0 +1,118 @@
+package request
+
+import (
+	"compress/gzip"
+	"context"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// compressServer 按 Content-Encoding 解压请求体并返回解压后的内容
+func compressServer(t *testing.T, encodings *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*encodings = append(*encodings, r.Header.Get("Content-Encoding"))
+		var reader io.Reader = r.Body
+		var err error
+		switch r.Header.Get("Content-Encoding") {
+		case "gzip":
+			reader, err = gzip.NewReader(r.Body)
+		case "zstd":
+			reader, err = zstd.NewReader(r.Body)
+		case "br":
+			reader = brotli.NewReader(r.Body)
+		}
+		if err != nil {
+			t.Errorf("%s 解压失败：%v", r.Header.Get("Content-Encoding"), err)
+			return
+		}
+		_, _ = io.Copy(w, reader)
+	}))
+}
+
+// 测试请求体超过阈值时压缩
+func TestWithRequestCompression(t *testing.T) {
+	var encodings []string
+	server := compressServer(t, &encodings)
+	defer server.Close()
+
+	client := NewClient(WithRequestCompression(CompressConf{Threshold: 100}))
+	large := map[string]any{"data": strings.Repeat("a", 200)}
+	header := map[string]string{"Content-Type": ApplicationJson}
+	body, err := client.Post(context.Background(), server.URL, large, header)
+	if err != nil || !strings.Contains(string(body), strings.Repeat("a", 200)) {
+		t.Errorf("Post() = %s, %v", body, err)
+	}
+	if _, err = client.Post(context.Background(), server.URL, map[string]any{"data": "a"}, header); err != nil {
+		t.Errorf("Post() error = %v", err)
+	}
+	if body, _ = client.Post(context.Background(), server.URL, large, nil); string(body) != "data="+strings.Repeat("a", 200) {
+		t.Errorf("form 请求体 = %s", body)
+	}
+	if strings.Join(encodings, ",") != "gzip,,gzip" {
+		t.Errorf("Content-Encoding = %v; want [gzip  gzip]", encodings)
+	}
+}
+
+// 测试内置的 zstd 压缩，非内置格式没有设置 Encoder 时超过阈值的请求返回错误，设置后使用自定义实现
+func TestWithRequestCompression_Encoder(t *testing.T) {
+	var encodings []string
+	server := compressServer(t, &encodings)
+	defer server.Close()
+
+	large := map[string]any{"data": strings.Repeat("a", 2000)}
+	want := "data=" + strings.Repeat("a", 2000)
+	for _, level := range []int{0, 19} {
+		client := NewClient(WithRequestCompression(CompressConf{Encoding: "zstd", Level: level}))
+		if body, err := client.Post(context.Background(), server.URL, large, nil); err != nil || string(body) != want {
+			t.Errorf("zstd 级别 %d Post() = %q, %v", level, body, err)
+		}
+	}
+
+	client := NewClient(WithRequestCompression(CompressConf{Encoding: "br"}), WithLogger(&memoryLogger{}))
+	if _, err := client.Post(context.Background(), server.URL, large, nil); err == nil || !strings.Contains(err.Error(), "br") {
+		t.Errorf("没有 Encoder 时 error = %v", err)
+	}
+
+	encoder := func(w io.Writer) (io.WriteCloser, error) { return brotli.NewWriter(w), nil }
+	client = NewClient(WithRequestCompression(CompressConf{Encoding: "br", Encoder: encoder}))
+	if body, err := client.Post(context.Background(), server.URL, large, nil); err != nil || string(body) != want {
+		t.Errorf("自定义 Encoder Post() error = %v", err)
+	}
+	if strings.Join(encodings, ",") != "zstd,zstd,br" {
+		t.Errorf("Content-Encoding = %v; want [zstd zstd br]", encodings)
+	}
+}
+
+// 测试流式编码压缩 json 请求体
+func TestWithRequestCompression_Stream(t *testing.T) {
+	var encodings []string
+	server := compressServer(t, &encodings)
+	defer server.Close()
+
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	items := make([]item, 1000)
+	for i := range items {
+		items[i] = item{ID: i, Name: "item"}
+	}
+	client := NewClient(WithRequestCompression(CompressConf{Stream: true}))
+	result, err := PostJSON[[]item](context.Background(), client, server.URL, items, nil)
+	if err != nil || len(result) != 1000 || result[999].ID != 999 {
+		t.Errorf("PostJSON() 返回 %d 条, error = %v", len(result), err)
+	}
+	if len(encodings) != 1 || encodings[0] != "gzip" {
+		t.Errorf("Content-Encoding = %v", encodings)
+	}
+
+	// 编码失败时请求返回错误
+	if _, err = client.Post(context.Background(), server.URL, map[string]any{"ch": make(chan int)}, map[string]string{"Content-Type": ApplicationJson}); err == nil {
+		t.Errorf("无法编码的请求参数应返回错误")
+	}
+}
@@ -0,0 +1,106 @@
+package request
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// WithCurlOnError 请求失败时在日志中输出脱敏后的 curl 命令，便于复现上游问题
+func WithCurlOnError() Option {
+	return func(c *Client) {
+		c.curlOnError = true
+	}
+}
+
+// CurlCommand 将请求转换为等价的 curl 命令，redactor 为空时只脱敏 Authorization、Cookie 等默认请求头
+// 请求体无法重新读取时（例如 multipart 流式上传）命令中不包含请求体，请求体经过压缩时以 shell 注释代替
+func CurlCommand(req *http.Request, redactor *Redactor) string {
+	if redactor == nil {
+		redactor = &Redactor{}
+	}
+
+	parts := []string{"curl"}
+	switch req.Method {
+	case http.MethodGet, "":
+	case http.MethodHead:
+		parts = append(parts, "--head")
+	default:
+		parts = append(parts, "-X", req.Method)
+	}
+	parts = append(parts, shellQuote(redactor.url(req.URL.String())))
+
+	header := make(map[string]string, len(req.Header))
+	for k, vs := range req.Header {
+		header[k] = strings.Join(vs, ", ")
+	}
+	header = redactor.header(header)
+	names := make([]string, 0, len(header))
+	for k := range header {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		parts = append(parts, "-H", shellQuote(k+": "+header[k]))
+	}
+
+	body, ok := replayableBody(req)
+	switch {
+	case !ok || len(body) == 0:
+	case req.Header.Get("Content-Encoding") != "":
+		parts = append(parts, "# "+encodedBodyNote(req.Header.Get("Content-Encoding"), int64(len(body))))
+	default:
+		parts = append(parts, "--data-raw", shellQuote(redactBody(redactor, req.Header.Get("Content-Type"), body)))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// replayableBody 读取可以重新读取的请求体，不影响请求本身
+func replayableBody(req *http.Request) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+
+	return body, err == nil
+}
+
+// redactBody 请求体脱敏，form 请求按字段脱敏，其余按 json 脱敏
+func redactBody(redactor *Redactor, contentType string, body []byte) string {
+	if !strings.HasPrefix(contentType, ApplicationForm) || len(redactor.Fields) == 0 {
+		return redactor.body(body)
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return redactor.truncate(string(body))
+	}
+	for k := range values {
+		if redactor.isField(k) {
+			values[k] = []string{redactedValue}
+		}
+	}
+
+	return redactor.truncate(values.Encode())
+}
+
+// encodedBodyNote 压缩后的请求体无法作为文本展示，使用说明代替
+func encodedBodyNote(encoding string, size int64) string {
+	return fmt.Sprintf("请求体经过 %s 编码，共 %d 字节，未包含", encoding, size)
+}
+
+// shellQuote 使用单引号转义 shell 参数
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,512 @@
+package request
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultChunkSize 并行下载时每个分块的默认最小字节数
+const defaultChunkSize int64 = 4 << 20
+
+var (
+	// ErrIncompleteDownload 下载的字节数与 Content-Length 不一致
+	ErrIncompleteDownload = errors.New("下载内容长度与 Content-Length 不一致")
+	// ErrChecksumMismatch 下载内容的校验值不一致
+	ErrChecksumMismatch = errors.New("下载内容校验失败")
+)
+
+// DownloadOptions 下载配置
+type DownloadOptions struct {
+	// Header 请求头
+	Header map[string]string
+	// Checksum 期望的校验值（十六进制），为空时不校验
+	Checksum string
+	// Hash 校验算法，默认 sha256
+	Hash func() hash.Hash
+	// Progress 下载进度回调，written 为已下载字节数，total 未知时为 -1
+	Progress func(written, total int64)
+	// Resume 下载到文件时，文件已存在则通过 Range 请求从已下载的位置继续下载，
+	// 并行下载时根据 path.parts 进度文件跳过已下载的部分，没有进度文件时重新下载
+	Resume bool
+	// Concurrency 下载到文件时的并行分块数，服务端支持 Range 请求且文件足够大时生效
+	Concurrency int
+	// ChunkSize 并行下载时每个分块的最小字节数，默认 4MB
+	ChunkSize int64
+}
+
+// Download 流式下载到 w，返回写入的字节数
+// 下载不受客户端默认超时时间限制，需要超时控制时通过 ctx 设置
+func (c *Client) Download(ctx context.Context, reqUrl string, w io.Writer, opts *DownloadOptions) (int64, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	ctx, span := c.startSpan(ctx, http.MethodGet, reqUrl)
+	var (
+		n          int64
+		statusCode int
+		err        error
+	)
+	defer func() {
+		endSpan(span, statusCode, int(n), 1, err)
+	}()
+
+	resp, err := c.openRange(ctx, reqUrl, opts.Header, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+	statusCode = resp.StatusCode
+	defer resp.Body.Close()
+	if !c.accept(resp.StatusCode) {
+		err = c.downloadStatusError(resp)
+		return 0, err
+	}
+
+	var h hash.Hash
+	if opts.Checksum != "" {
+		h = opts.newHash()
+		w = io.MultiWriter(w, h)
+	}
+	counter := newProgressCounter(opts.Progress, resp.ContentLength, 0)
+	n, err = io.Copy(io.MultiWriter(w, counter), resp.Body)
+	if err != nil {
+		c.logger.Errorf(ctx, "下载失败，已下载%d字节: %s", n, err)
+		return n, err
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		err = ErrIncompleteDownload
+	} else if h != nil && !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), opts.Checksum) {
+		err = ErrChecksumMismatch
+	}
+
+	return n, err
+}
+
+// DownloadFile 下载到文件，返回文件大小
+// 下载中断时按客户端的重试策略从已下载的位置继续下载，并行下载时每个分块单独重试
+func (c *Client) DownloadFile(ctx context.Context, reqUrl, path string, opts *DownloadOptions) (int64, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	ctx, span := c.startSpan(ctx, http.MethodGet, reqUrl)
+	var (
+		size int64
+		err  error
+	)
+	defer func() {
+		endSpan(span, 0, int(size), 1, err)
+	}()
+
+	size, ok, err := c.downloadParallel(ctx, reqUrl, path, opts)
+	if !ok {
+		size, err = c.downloadResumable(ctx, reqUrl, path, opts)
+	}
+	if err != nil {
+		return size, err
+	}
+	if opts.Checksum != "" {
+		err = opts.verifyFile(path)
+	}
+
+	return size, err
+}
+
+// downloadResumable 单连接下载到文件，支持断点续传
+func (c *Client) downloadResumable(ctx context.Context, reqUrl, path string, opts *DownloadOptions) (int64, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var offset int64
+	if opts.Resume {
+		info, err := file.Stat()
+		if err != nil {
+			return 0, err
+		}
+		offset = info.Size()
+	} else if err = file.Truncate(0); err != nil {
+		return 0, err
+	}
+
+	maxAttempts := c.retry.maxAttempts(http.MethodGet)
+	for attempt := 1; ; attempt++ {
+		offset, err = c.downloadFrom(ctx, reqUrl, file, offset, opts)
+		if err == nil || attempt >= maxAttempts || ctx.Err() != nil || !c.retry.shouldRetry(0, err) {
+			return offset, err
+		}
+
+		delay := c.retry.delay(attempt, 0, nil)
+		c.logger.Errorf(ctx, "第%d次下载中断，已下载%d字节，%s后继续下载: %s", attempt, offset, delay, err)
+		if err = sleep(ctx, delay); err != nil {
+			return offset, err
+		}
+	}
+}
+
+// downloadFrom 从 offset 处继续下载，返回下载后的文件大小
+func (c *Client) downloadFrom(ctx context.Context, reqUrl string, file *os.File, offset int64, opts *DownloadOptions) (int64, error) {
+	resp, err := c.openRange(ctx, reqUrl, opts.Header, offset, -1)
+	if err != nil {
+		return offset, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// 文件已下载完成
+		if _, _, total := parseContentRange(resp.Header.Get("Content-Range")); total == offset {
+			return offset, nil
+		}
+		return offset, c.downloadStatusError(resp)
+	case resp.StatusCode == http.StatusPartialContent:
+		if start, _, _ := parseContentRange(resp.Header.Get("Content-Range")); start != offset {
+			return offset, fmt.Errorf("Content-Range 起始位置 %d 与请求位置 %d 不一致", start, offset)
+		}
+	case c.accept(resp.StatusCode):
+		// 服务端不支持 Range 请求，从头下载
+		if offset > 0 {
+			if err = file.Truncate(0); err != nil {
+				return offset, err
+			}
+			offset = 0
+		}
+	default:
+		return offset, c.downloadStatusError(resp)
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	counter := newProgressCounter(opts.Progress, total, offset)
+	n, err := io.Copy(io.MultiWriter(io.NewOffsetWriter(file, offset), counter), resp.Body)
+	offset += n
+	if err != nil {
+		return offset, err
+	}
+	if total >= 0 && offset != total {
+		return offset, ErrIncompleteDownload
+	}
+
+	return offset, nil
+}
+
+// downloadParallel 分块并行下载，服务端不支持 Range 请求或文件太小时返回 false
+// 每个分块按客户端的重试策略从已下载的位置继续下载，未完成时将进度写入 path.parts，Resume 时跳过已下载的部分
+func (c *Client) downloadParallel(ctx context.Context, reqUrl, path string, opts *DownloadOptions) (int64, bool, error) {
+	if opts.Concurrency <= 1 {
+		return 0, false, nil
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+
+	head, err := c.Head(ctx, reqUrl, nil, opts.Header)
+	if err != nil || head.Header.Get("Accept-Ranges") != "bytes" {
+		return 0, false, nil
+	}
+	size, err := strconv.ParseInt(head.Header.Get("Content-Length"), 10, 64)
+	if err != nil || size < 2*chunkSize {
+		return 0, false, nil
+	}
+	if per := (size + int64(opts.Concurrency) - 1) / int64(opts.Concurrency); per > chunkSize {
+		chunkSize = per
+	}
+
+	partsPath := path + partsSuffix
+	parts := newDownloadParts(size, chunkSize, head.Header.Get("ETag"))
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if opts.Resume && parts.load(partsPath) {
+		flag = os.O_CREATE | os.O_WRONLY
+	}
+	file, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return 0, true, err
+	}
+	defer file.Close()
+	if err = file.Truncate(size); err != nil {
+		return 0, true, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	counter := newProgressCounter(opts.Progress, size, parts.written())
+	for i := range parts.Done {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := c.downloadChunk(ctx, reqUrl, file, parts, i, opts.Header, counter); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			parts.save(partsPath)
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		parts.save(partsPath)
+		return parts.written(), true, firstErr
+	}
+	_ = os.Remove(partsPath)
+
+	return size, true, nil
+}
+
+// downloadChunk 下载第 i 个分块，中断时按客户端的重试策略从分块内已下载的位置继续下载
+func (c *Client) downloadChunk(ctx context.Context, reqUrl string, file *os.File, parts *downloadParts, i int, header map[string]string, counter io.Writer) error {
+	maxAttempts := c.retry.maxAttempts(http.MethodGet)
+	for attempt := 1; ; attempt++ {
+		start, end := parts.remaining(i)
+		if start > end {
+			return nil
+		}
+		err := c.downloadRange(ctx, reqUrl, file, parts, i, start, end, header, counter)
+		if err == nil || attempt >= maxAttempts || ctx.Err() != nil || !c.retry.shouldRetry(0, err) {
+			return err
+		}
+
+		delay := c.retry.delay(attempt, 0, nil)
+		c.logger.Errorf(ctx, "第%d个分块第%d次下载中断，%s后继续下载: %s", i+1, attempt, delay, err)
+		if err = sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// downloadRange 下载 [start, end] 区间的内容并写入文件对应位置，同时记录分块进度
+func (c *Client) downloadRange(ctx context.Context, reqUrl string, file *os.File, parts *downloadParts, i int, start, end int64, header map[string]string, counter io.Writer) error {
+	resp, err := c.openRange(ctx, reqUrl, header, start, end)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return c.downloadStatusError(resp)
+	}
+
+	n, err := io.Copy(io.MultiWriter(io.NewOffsetWriter(file, start), partWriter{parts: parts, index: i}, counter), resp.Body)
+	if err != nil {
+		return err
+	}
+	if n != end-start+1 {
+		return ErrIncompleteDownload
+	}
+
+	return nil
+}
+
+// partsSuffix 并行下载进度文件的后缀
+const partsSuffix = ".parts"
+
+// downloadParts 并行下载进度，Done 为每个分块已下载的字节数
+type downloadParts struct {
+	mu        sync.Mutex
+	Size      int64   `json:"size"`
+	ChunkSize int64   `json:"chunkSize"`
+	ETag      string  `json:"etag,omitempty"`
+	Done      []int64 `json:"done"`
+}
+
+// newDownloadParts 初始化下载进度
+func newDownloadParts(size, chunkSize int64, etag string) *downloadParts {
+	return &downloadParts{
+		Size:      size,
+		ChunkSize: chunkSize,
+		ETag:      etag,
+		Done:      make([]int64, (size+chunkSize-1)/chunkSize),
+	}
+}
+
+// load 读取进度文件，文件大小、分块大小与 ETag 都一致时使用其中的进度
+func (p *downloadParts) load(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var saved downloadParts
+	if err = json.Unmarshal(data, &saved); err != nil {
+		return false
+	}
+	if saved.Size != p.Size || saved.ChunkSize != p.ChunkSize || saved.ETag != p.ETag || len(saved.Done) != len(p.Done) {
+		return false
+	}
+	copy(p.Done, saved.Done)
+
+	return true
+}
+
+// save 写入进度文件
+func (p *downloadParts) save(path string) {
+	p.mu.Lock()
+	data, err := json.Marshal(p)
+	p.mu.Unlock()
+	if err == nil {
+		_ = os.WriteFile(path, data, 0o644)
+	}
+}
+
+// remaining 第 i 个分块未下载的区间
+func (p *downloadParts) remaining(i int) (int64, int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	start := int64(i) * p.ChunkSize
+
+	return start + p.Done[i], min(start+p.ChunkSize, p.Size) - 1
+}
+
+// written 已下载的字节数
+func (p *downloadParts) written() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var n int64
+	for _, done := range p.Done {
+		n += done
+	}
+
+	return n
+}
+
+// partWriter 记录分块已写入文件的字节数
+type partWriter struct {
+	parts *downloadParts
+	index int
+}
+
+func (w partWriter) Write(b []byte) (int, error) {
+	w.parts.mu.Lock()
+	w.parts.Done[w.index] += int64(len(b))
+	w.parts.mu.Unlock()
+
+	return len(b), nil
+}
+
+// openRange 发起 get 请求，start 大于 0 或 end 不小于 0 时携带 Range 请求头
+func (c *Client) openRange(ctx context.Context, reqUrl string, header map[string]string, start, end int64) (*http.Response, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.resolveURL(reqUrl), nil, c.mergeHeader(header))
+	if err != nil {
+		return nil, err
+	}
+	if end >= 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	} else if start > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", start))
+	}
+
+	resp, err := c.handler(req)
+	if err != nil {
+		c.logger.Errorf(ctx, "下载请求失败: %s", err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// downloadStatusError 读取部分返回内容生成 StatusError
+func (c *Client) downloadStatusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+
+	return newStatusError(resp.Request, &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body})
+}
+
+// parseContentRange 解析 Content-Range，格式为 bytes start-end/total 或 bytes */total，未知部分返回 -1
+func parseContentRange(value string) (start, end, total int64) {
+	start, end, total = -1, -1, -1
+	value, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return
+	}
+	rng, size, ok := strings.Cut(value, "/")
+	if !ok {
+		return
+	}
+	if n, err := strconv.ParseInt(size, 10, 64); err == nil {
+		total = n
+	}
+	if from, to, ok := strings.Cut(rng, "-"); ok {
+		if n, err := strconv.ParseInt(from, 10, 64); err == nil {
+			start = n
+		}
+		if n, err := strconv.ParseInt(to, 10, 64); err == nil {
+			end = n
+		}
+	}
+
+	return
+}
+
+// newHash 校验算法
+func (o *DownloadOptions) newHash() hash.Hash {
+	if o.Hash != nil {
+		return o.Hash()
+	}
+
+	return sha256.New()
+}
+
+// verifyFile 校验文件内容
+func (o *DownloadOptions) verifyFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	h := o.newHash()
+	if _, err = io.Copy(h, file); err != nil {
+		return err
+	}
+	if !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), o.Checksum) {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+// progressCounter 统计下载字节数并回调进度，可被多个分块并发写入
+type progressCounter struct {
+	mu       sync.Mutex
+	written  int64
+	total    int64
+	progress func(written, total int64)
+}
+
+// newProgressCounter 初始化进度统计，written 为已下载的字节数
+func newProgressCounter(progress func(written, total int64), total, written int64) *progressCounter {
+	return &progressCounter{written: written, total: total, progress: progress}
+}
+
+func (p *progressCounter) Write(b []byte) (int, error) {
+	if p.progress == nil {
+		return len(b), nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.written += int64(len(b))
+	p.progress(p.written, p.total)
+
+	return len(b), nil
+}
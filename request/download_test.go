@@ -0,0 +1,215 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// downloadContent 测试下载内容
+var downloadContent = []byte(strings.Repeat("0123456789", 1000))
+
+// downloadServer 支持 Range 请求的文件服务
+func downloadServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.txt", time.Time{}, bytes.NewReader(downloadContent))
+	}))
+}
+
+// checksum 计算 sha256
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// 测试流式下载到 io.Writer 并校验
+func TestClient_Download(t *testing.T) {
+	server := downloadServer()
+	defer server.Close()
+
+	var buf bytes.Buffer
+	var written int64
+	opts := &DownloadOptions{Checksum: checksum(downloadContent), Progress: func(n, total int64) { written = n }}
+	n, err := NewClient().Download(context.Background(), server.URL, &buf, opts)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if n != int64(len(downloadContent)) || !bytes.Equal(buf.Bytes(), downloadContent) || written != n {
+		t.Errorf("Download() n = %d, written = %d; want %d", n, written, len(downloadContent))
+	}
+
+	_, err = NewClient().Download(context.Background(), server.URL, &bytes.Buffer{}, &DownloadOptions{Checksum: "abc"})
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Download() error = %v; want ErrChecksumMismatch", err)
+	}
+}
+
+// 测试已存在的部分文件断点续传
+func TestClient_DownloadFileResume(t *testing.T) {
+	server := downloadServer()
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, downloadContent[:3000], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := &DownloadOptions{Resume: true, Checksum: checksum(downloadContent)}
+	n, err := NewClient().DownloadFile(context.Background(), server.URL, path, opts)
+	if err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	if n != int64(len(downloadContent)) || !bytes.Equal(got, downloadContent) {
+		t.Errorf("DownloadFile() n = %d, 文件内容不一致", n)
+	}
+
+	// 文件已完整时再次续传
+	if n, err = NewClient().DownloadFile(context.Background(), server.URL, path, opts); err != nil || n != int64(len(downloadContent)) {
+		t.Errorf("DownloadFile() 已完成文件续传 n = %d, error = %v", n, err)
+	}
+}
+
+// 测试下载中断后按重试策略续传
+func TestClient_DownloadFileInterrupted(t *testing.T) {
+	var count int32
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if atomic.AddInt32(&count, 1) == 1 {
+			w.Header().Set("Content-Length", strconv.Itoa(len(downloadContent)))
+			_, _ = w.Write(downloadContent[:4000])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "data.txt", time.Time{}, bytes.NewReader(downloadContent))
+	}))
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	path := filepath.Join(t.TempDir(), "data.txt")
+	_, err := NewClient(WithRetry(policy)).DownloadFile(context.Background(), server.URL, path, &DownloadOptions{Checksum: checksum(downloadContent)})
+	if err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if len(ranges) != 2 || ranges[1] != "bytes=4000-" {
+		t.Errorf("续传的 Range 请求头 = %v; want [ bytes=4000-]", ranges)
+	}
+}
+
+// 测试分块并行下载
+func TestClient_DownloadFileParallel(t *testing.T) {
+	var rangeCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			atomic.AddInt32(&rangeCount, 1)
+		}
+		http.ServeContent(w, r, "data.txt", time.Time{}, bytes.NewReader(downloadContent))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "data.txt")
+	opts := &DownloadOptions{Concurrency: 4, ChunkSize: 1000, Checksum: checksum(downloadContent)}
+	n, err := NewClient().DownloadFile(context.Background(), server.URL, path, opts)
+	if err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if n != int64(len(downloadContent)) || atomic.LoadInt32(&rangeCount) != 4 {
+		t.Errorf("DownloadFile() n = %d, 分块请求数 = %d; want %d, 4", n, rangeCount, len(downloadContent))
+	}
+}
+
+// flakyRangeServer 前 failures 次请求 failRange 时只返回前 100 字节，等待其他分块完成后中断连接
+func flakyRangeServer(failRange string, failures int32) (*httptest.Server, *[]string, *sync.Mutex) {
+	var (
+		mu     sync.Mutex
+		ranges []string
+		failed int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rng := r.Header.Get("Range")
+		mu.Lock()
+		if rng != "" {
+			ranges = append(ranges, rng)
+		}
+		mu.Unlock()
+		if rng == failRange && atomic.AddInt32(&failed, 1) <= failures {
+			start, end, _ := strings.Cut(strings.TrimPrefix(rng, "bytes="), "-")
+			from, _ := strconv.Atoi(start)
+			to, _ := strconv.Atoi(end)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", from, to, len(downloadContent)))
+			w.Header().Set("Content-Length", strconv.Itoa(to-from+1))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(downloadContent[from : from+100])
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "data.txt", time.Time{}, bytes.NewReader(downloadContent))
+	}))
+
+	return server, &ranges, &mu
+}
+
+// 测试并行下载的分块中断后按重试策略从分块内已下载的位置继续下载
+func TestClient_DownloadFileParallelRetry(t *testing.T) {
+	server, ranges, mu := flakyRangeServer("bytes=2500-4999", 1)
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	path := filepath.Join(t.TempDir(), "data.txt")
+	opts := &DownloadOptions{Concurrency: 4, ChunkSize: 1000, Checksum: checksum(downloadContent)}
+	if _, err := NewClient(WithRetry(policy), WithLogger(&memoryLogger{})).DownloadFile(context.Background(), server.URL, path, opts); err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(*ranges) != 5 || !strings.Contains(strings.Join(*ranges, ","), "bytes=2600-4999") {
+		t.Errorf("Range 请求头 = %v; want 包含 bytes=2600-4999", *ranges)
+	}
+	if _, err := os.Stat(path + partsSuffix); !os.IsNotExist(err) {
+		t.Errorf("下载完成后进度文件未删除")
+	}
+}
+
+// 测试并行下载失败后 Resume 只下载未完成的部分
+func TestClient_DownloadFileParallelResume(t *testing.T) {
+	server, ranges, mu := flakyRangeServer("bytes=2500-4999", 1)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "data.txt")
+	opts := &DownloadOptions{Concurrency: 4, ChunkSize: 1000, Resume: true, Checksum: checksum(downloadContent)}
+	client := NewClient(WithLogger(&memoryLogger{}))
+	if _, err := client.DownloadFile(context.Background(), server.URL, path, opts); err == nil {
+		t.Fatalf("DownloadFile() 期望分块下载失败")
+	}
+	if _, err := os.Stat(path + partsSuffix); err != nil {
+		t.Fatalf("下载失败后没有进度文件：%v", err)
+	}
+
+	mu.Lock()
+	*ranges = nil
+	mu.Unlock()
+	if _, err := client.DownloadFile(context.Background(), server.URL, path, opts); err != nil {
+		t.Fatalf("DownloadFile() 续传 error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(*ranges) != 1 || (*ranges)[0] != "bytes=2600-4999" {
+		t.Errorf("续传的 Range 请求头 = %v; want [bytes=2600-4999]", *ranges)
+	}
+}
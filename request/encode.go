@@ -0,0 +1,281 @@
+package request
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeType time.Time 的类型
+var timeType = reflect.TypeOf(time.Time{})
+
+// textMarshalerType encoding.TextMarshaler 的类型
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// stringerType fmt.Stringer 的类型
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// fieldOptions 字段标签中的选项
+type fieldOptions struct {
+	// omitEmpty 零值、空切片与空 map 时忽略
+	omitEmpty bool
+	// comma 切片用逗号拼接为一个参数，默认为重复的参数名
+	comma bool
+	// brackets 切片的参数名加上 []，例如 ids[]=1&ids[]=2
+	brackets bool
+	// dot 嵌套结构体与 map 使用 user.name 格式，默认为 user[name]
+	dot bool
+	// unix 时间编码为秒级时间戳
+	unix bool
+	// unixMilli 时间编码为毫秒级时间戳
+	unixMilli bool
+	// layout 时间格式，默认为 RFC3339
+	layout string
+	// rawBytes []byte 编码为字符串，用于 map[string]any 的值，结构体字段的 []byte 与其他切片一样逐个编码
+	rawBytes bool
+}
+
+// EncodeQuery 将结构体按 query 标签编码为查询参数，标签格式为 `query:"name,omitempty"`
+// 支持的选项：omitempty 忽略零值，comma 切片逗号拼接，brackets 切片参数名加 []，dot 嵌套字段使用点号，
+// unix、unixmilli 时间编码为时间戳，时间格式通过 layout 标签设置，例如 `layout:"2006-01-02"`
+// 实现 encoding.TextMarshaler 或 fmt.Stringer 的值（例如 time.Duration）编码为对应的文本
+// 没有标签的字段使用字段名，标签为 - 的字段忽略，指针为空时忽略
+func EncodeQuery(v any) (url.Values, error) {
+	return encodeTagged(v, "query")
+}
+
+// EncodeForm 将结构体按 form 标签编码为表单参数，标签规则与 EncodeQuery 一致
+func EncodeForm(v any) (url.Values, error) {
+	return encodeTagged(v, "form")
+}
+
+// encodeTagged 按指定标签编码结构体
+func encodeTagged(v any, tag string) (url.Values, error) {
+	values := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("不支持的请求参数类型：%T", v)
+	}
+	if err := encodeStruct(values, rv, "", false, tag); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// encodeStruct 编码结构体的所有字段，prefix 为嵌套结构体的参数名前缀
+func encodeStruct(values url.Values, v reflect.Value, prefix string, dot bool, tag string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts := parseFieldTag(sf, tag)
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+
+		// 没有标签的匿名结构体字段展开到当前层级
+		if sf.Anonymous && sf.Tag.Get(tag) == "" {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := encodeStruct(values, fv, prefix, dot, tag); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if opts.omitEmpty && isEmpty(fv) {
+			continue
+		}
+		if err := encodeField(values, joinKey(prefix, name, dot), fv, opts, dot || opts.dot, tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeField 编码单个字段
+func encodeField(values url.Values, key string, v reflect.Value, opts fieldOptions, dot bool, tag string) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if s, ok, err := formatScalar(v, opts); ok {
+		if err != nil {
+			return fmt.Errorf("参数 %s 编码失败：%w", key, err)
+		}
+		values.Add(key, s)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			for elem.Kind() == reflect.Pointer || elem.Kind() == reflect.Interface {
+				if elem.IsNil() {
+					break
+				}
+				elem = elem.Elem()
+			}
+			s, ok, err := formatScalar(elem, opts)
+			if err != nil {
+				return fmt.Errorf("参数 %s 编码失败：%w", key, err)
+			}
+			if !ok {
+				// 结构体等复杂类型使用下标作为嵌套参数名
+				if err = encodeField(values, joinKey(key, strconv.Itoa(i), dot), elem, opts, dot, tag); err != nil {
+					return err
+				}
+				continue
+			}
+			items = append(items, s)
+		}
+		switch {
+		case len(items) == 0:
+		case opts.comma:
+			values.Add(key, strings.Join(items, ","))
+		case opts.brackets:
+			values[key+"[]"] = append(values[key+"[]"], items...)
+		default:
+			values[key] = append(values[key], items...)
+		}
+	case reflect.Struct:
+		return encodeStruct(values, v, key, dot, tag)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("参数 %s 编码失败：map 的键必须为字符串", key)
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, k := range keys {
+			if err := encodeField(values, joinKey(key, k.String(), dot), v.MapIndex(k), opts, dot, tag); err != nil {
+				return err
+			}
+		}
+	case reflect.Invalid:
+	default:
+		return fmt.Errorf("参数 %s 编码失败：不支持的类型 %s", key, v.Type())
+	}
+
+	return nil
+}
+
+// formatScalar 将基础类型、时间与实现 encoding.TextMarshaler 或 fmt.Stringer 的值转换为字符串，不是这些类型时 ok 为 false
+// 实现 fmt.Stringer 的结构体仍按字段编码
+func formatScalar(v reflect.Value, opts fieldOptions) (s string, ok bool, err error) {
+	if !v.IsValid() {
+		return "", false, nil
+	}
+	if opts.rawBytes && v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return string(v.Bytes()), true, nil
+	}
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		switch {
+		case opts.unix:
+			return strconv.FormatInt(t.Unix(), 10), true, nil
+		case opts.unixMilli:
+			return strconv.FormatInt(t.UnixMilli(), 10), true, nil
+		case opts.layout != "":
+			return t.Format(opts.layout), true, nil
+		default:
+			return t.Format(time.RFC3339), true, nil
+		}
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), true, err
+	}
+	if v.Kind() != reflect.Struct && v.Type().Implements(stringerType) {
+		return v.Interface().(fmt.Stringer).String(), true, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// parseFieldTag 解析字段标签，没有标签时使用字段名
+func parseFieldTag(sf reflect.StructField, tag string) (string, fieldOptions) {
+	name, rest, _ := strings.Cut(sf.Tag.Get(tag), ",")
+	if name == "" {
+		name = sf.Name
+	}
+	opts := fieldOptions{layout: sf.Tag.Get("layout")}
+	for _, opt := range strings.Split(rest, ",") {
+		switch opt {
+		case "omitempty":
+			opts.omitEmpty = true
+		case "comma":
+			opts.comma = true
+		case "brackets":
+			opts.brackets = true
+		case "dot":
+			opts.dot = true
+		case "unix":
+			opts.unix = true
+		case "unixmilli":
+			opts.unixMilli = true
+		}
+	}
+
+	return name, opts
+}
+
+// joinKey 拼接嵌套参数名
+func joinKey(prefix, name string, dot bool) string {
+	if prefix == "" {
+		return name
+	}
+	if dot {
+		return prefix + "." + name
+	}
+
+	return prefix + "[" + name + "]"
+}
+
+// isEmpty 判断字段是否为空，切片与 map 长度为 0 时也视为空
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,150 @@
+package request
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type pageQuery struct {
+	Page int  `query:"page"`
+	Size uint `query:"size,omitempty"`
+}
+
+type userFilter struct {
+	pageQuery
+	Name      string            `query:"name"`
+	Tags      []string          `query:"tags"`
+	IDs       []int64           `query:"ids,comma"`
+	Types     []uint8           `query:"types,brackets"`
+	Active    *bool             `query:"active"`
+	Deleted   *bool             `query:"deleted"`
+	Since     time.Time         `query:"since" layout:"2006-01-02"`
+	Until     time.Time         `query:"until,unix"`
+	Score     float32           `query:"score,omitempty"`
+	Owner     owner             `query:"owner"`
+	Meta      owner             `query:"meta,dot"`
+	Labels    map[string]string `query:"labels"`
+	IP        net.IP            `query:"ip,omitempty"`
+	Ignored   string            `query:"-"`
+	NoTag     int8
+	unexposed string
+}
+
+type owner struct {
+	ID   int    `query:"id" form:"id"`
+	Name string `query:"name,omitempty" form:"name"`
+}
+
+// 测试结构体编码为查询参数
+func TestEncodeQuery(t *testing.T) {
+	active := true
+	filter := &userFilter{
+		pageQuery: pageQuery{Page: 2},
+		Name:      "tom",
+		Tags:      []string{"a", "b"},
+		IDs:       []int64{1, 2, 3},
+		Types:     []uint8{4, 5},
+		Active:    &active,
+		Since:     time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Until:     time.Unix(1700000000, 0),
+		Owner:     owner{ID: 7, Name: "jerry"},
+		Meta:      owner{ID: 8},
+		Labels:    map[string]string{"env": "prod"},
+		Ignored:   "x",
+		NoTag:     -1,
+		unexposed: "y",
+	}
+	values, err := EncodeQuery(filter)
+	if err != nil {
+		t.Fatalf("EncodeQuery() error = %v", err)
+	}
+	want := url.Values{
+		"page":        {"2"},
+		"name":        {"tom"},
+		"tags":        {"a", "b"},
+		"ids":         {"1,2,3"},
+		"types[]":     {"4", "5"},
+		"active":      {"true"},
+		"since":       {"2024-05-01"},
+		"until":       {"1700000000"},
+		"owner[id]":   {"7"},
+		"owner[name]": {"jerry"},
+		"meta.id":     {"8"},
+		"labels[env]": {"prod"},
+		"NoTag":       {"-1"},
+	}
+	if values.Encode() != want.Encode() {
+		t.Errorf("EncodeQuery() = %s; want %s", values.Encode(), want.Encode())
+	}
+
+	if _, err = EncodeQuery([]string{"a"}); err == nil {
+		t.Errorf("非结构体应返回错误")
+	}
+	if _, err = EncodeQuery(struct{ C chan int }{}); err == nil {
+		t.Errorf("不支持的字段类型应返回错误")
+	}
+}
+
+// 测试客户端使用结构体作为查询参数与表单参数
+func TestClient_StructParams(t *testing.T) {
+	var query, form string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_ = r.ParseForm()
+		form = r.PostForm.Encode()
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	if _, err := client.Get(context.Background(), server.URL+"?from=1", pageQuery{Page: 1, Size: 20}, nil); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if query != "from=1&page=1&size=20" {
+		t.Errorf("query = %s", query)
+	}
+	if _, err := client.Post(context.Background(), server.URL, &owner{ID: 1, Name: "tom"}, nil); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if form != "id=1&name=tom" {
+		t.Errorf("form = %s", form)
+	}
+}
+
+// 测试 DoRequest 使用的 map 参数与结构体字段使用相同的编码规则
+func TestDoRequest_MapParams(t *testing.T) {
+	var query, form string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_ = r.ParseForm()
+		form = r.PostForm.Encode()
+	}))
+	defer server.Close()
+
+	params := map[string]any{
+		"ids":   []string{"a", "b"},
+		"page":  1,
+		"ok":    true,
+		"since": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"user":  map[string]any{"name": "tom", "raw": []byte("cd")},
+		"raw":   []byte("ab"),
+		"wait":  2 * time.Second,
+	}
+	want := "ids=a&ids=b&ok=true&page=1&raw=ab&since=2024-01-02T03%3A04%3A05Z&user%5Bname%5D=tom&user%5Braw%5D=cd&wait=2s"
+	if _, err := DoRequest(context.Background(), server.URL, http.MethodGet, params, nil, 0); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	if got, _ := url.ParseQuery(query); got.Encode() != want {
+		t.Errorf("query = %s; want %s", query, want)
+	}
+	if _, err := DoRequest(context.Background(), server.URL, http.MethodPost, params, nil, 0); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	if form != want {
+		t.Errorf("form = %s; want %s", form, want)
+	}
+}
@@ -0,0 +1,71 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// maxErrorBodySize StatusError 中保留的返回内容最大字节数
+const maxErrorBodySize = 1024
+
+// StatusError 返回状态码不在可接受范围内时的错误，可通过 errors.As 获取
+type StatusError struct {
+	// Method 请求方法
+	Method string
+	// URL 请求地址
+	URL string
+	// StatusCode http状态码
+	StatusCode int
+	// Header 返回头
+	Header http.Header
+	// Body 返回内容，超过 1KB 时会被截断，可能包含敏感信息，不会出现在 Error() 中
+	Body []byte
+}
+
+// Error 实现 error 接口，不包含返回内容，避免错误日志与链路追踪绕过脱敏配置
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s 返回状态码 %d", e.Method, e.URL, e.StatusCode)
+}
+
+// newStatusError 根据请求与返回结果生成 StatusError
+func newStatusError(req *http.Request, resp *Response) *StatusError {
+	body := resp.Body
+	if len(body) > maxErrorBodySize {
+		body = body[:maxErrorBodySize]
+	}
+
+	return &StatusError{
+		Method:     req.Method,
+		URL:        req.URL.Redacted(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+}
+
+// StatusRange 状态码区间，包含 Min 与 Max
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// WithAcceptStatus 设置可接受的状态码区间，默认只接受 2xx
+func WithAcceptStatus(ranges ...StatusRange) Option {
+	return func(c *Client) {
+		c.acceptStatus = ranges
+	}
+}
+
+// accept 判断状态码是否在可接受范围内
+func (c *Client) accept(statusCode int) bool {
+	if len(c.acceptStatus) == 0 {
+		return statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices
+	}
+	for _, r := range c.acceptStatus {
+		if statusCode >= r.Min && statusCode <= r.Max {
+			return true
+		}
+	}
+
+	return false
+}
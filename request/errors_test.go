@@ -0,0 +1,50 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 测试非 2xx 返回 StatusError
+func TestStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "123")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(strings.Repeat("a", 2048)))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	resp, err := client.Do(context.Background(), http.MethodGet, server.URL+"/users", nil, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Do() error = %v; want *StatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusNotFound || statusErr.Method != http.MethodGet ||
+		statusErr.URL != server.URL+"/users" || statusErr.Header.Get("X-Request-Id") != "123" {
+		t.Errorf("StatusError 内容不符合预期: %+v", statusErr)
+	}
+	if len(statusErr.Body) != maxErrorBodySize {
+		t.Errorf("StatusError.Body 长度 = %d; want %d", len(statusErr.Body), maxErrorBodySize)
+	}
+	if resp == nil || len(resp.Body) != 2048 {
+		t.Errorf("Do() 应同时返回完整的 Response")
+	}
+}
+
+// 测试自定义可接受的状态码区间
+func TestWithAcceptStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithAcceptStatus(StatusRange{Min: 200, Max: 299}, StatusRange{Min: 404, Max: 404}))
+	if _, err := client.Get(context.Background(), server.URL, nil, nil); err != nil {
+		t.Errorf("Get() error = %v; want nil", err)
+	}
+}
@@ -0,0 +1,409 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// harVersion HAR 规范版本
+const harVersion = "1.2"
+
+// defaultHARBodySize 默认记录的最大内容长度
+const defaultHARBodySize = 1 << 20
+
+// HARConf HAR 录制配置
+type HARConf struct {
+	// Transport 实际发出请求的 RoundTripper，默认为 http.DefaultTransport
+	Transport http.RoundTripper
+	// Redactor 请求头、查询参数与内容脱敏，为空时只脱敏 Authorization、Cookie 等默认请求头
+	Redactor *Redactor
+	// MaxEntries 最多保留的记录数，超出时丢弃最早的记录，0 表示不限制
+	MaxEntries int
+	// MaxBodySize 记录的请求体与返回内容的最大字节数，超出时截断，默认 1MB
+	// 请求体在发送时、返回内容在读取时边读取边记录，不影响流式上传、客户端的 WithMaxBodySize 与解压限制，
+	// text/event-stream 的返回内容不记录
+	MaxBodySize int
+}
+
+// HAR HAR 1.2 文件内容，可以导入浏览器开发者工具或 Charles 等工具查看
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog HAR 日志
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator 生成 HAR 的工具
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry 一次请求与返回
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// HARRequest 请求内容
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse 返回内容
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue 名称与值
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData 请求体
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent 返回内容
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// HARTimings 各阶段耗时，单位毫秒，-1 表示不可用
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HARRecorder 记录请求与返回的 RoundTripper，配合 WithTransport 使用，可以导出为 HAR 文件
+type HARRecorder struct {
+	conf    HARConf
+	mu      sync.Mutex
+	entries []HAREntry
+}
+
+// NewHARRecorder 初始化 HAR 录制
+func NewHARRecorder(conf HARConf) *HARRecorder {
+	if conf.Transport == nil {
+		conf.Transport = http.DefaultTransport
+	}
+	if conf.Redactor == nil {
+		conf.Redactor = &Redactor{}
+	}
+	if conf.MaxBodySize <= 0 {
+		conf.MaxBodySize = defaultHARBodySize
+	}
+
+	return &HARRecorder{conf: conf}
+}
+
+// RoundTrip 实现 http.RoundTripper，请求失败时同样记录，错误信息写入 comment
+func (h *HARRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 请求体在 Transport 发送时记录，不提前读取
+	var reqBody *harBody
+	if req.Body != nil && req.Body != http.NoBody {
+		reqBody = &harBody{ReadCloser: req.Body, limit: h.conf.MaxBodySize}
+		tee := *req
+		tee.Body = reqBody
+		req = &tee
+	}
+
+	start := time.Now()
+	resp, err := h.conf.Transport.RoundTrip(req)
+	wait := time.Since(start)
+	entry := HAREntry{
+		StartedDateTime: start,
+		Request:         h.request(req, reqBody),
+		Response:        HARResponse{Cookies: []HARNameValue{}, Headers: []HARNameValue{}, HeadersSize: -1, BodySize: -1},
+	}
+	if err != nil {
+		entry.Comment = err.Error()
+		entry.Time = millis(wait)
+		entry.Timings = HARTimings{Send: 0, Wait: entry.Time, Receive: 0}
+		h.add(entry)
+		return nil, err
+	}
+
+	// 事件流不会结束，只记录返回头
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+		entry.Response = h.response(resp, nil, 0)
+		entry.Comment = "text/event-stream 返回内容未记录"
+		entry.Time = millis(wait)
+		entry.Timings = HARTimings{Send: 0, Wait: entry.Time, Receive: 0}
+		h.add(entry)
+		return resp, nil
+	}
+
+	// 返回内容在调用方读取时记录，读取结束或关闭时生成记录
+	resp.Body = &harBody{ReadCloser: resp.Body, limit: h.conf.MaxBodySize, finish: func(captured []byte, size int64, err error) {
+		total := time.Since(start)
+		if err != nil {
+			entry.Comment = err.Error()
+		}
+		// 服务端可能在请求体发送完之前返回，读取结束时重新记录请求体
+		entry.Request = h.request(req, reqBody)
+		entry.Response = h.response(resp, captured, size)
+		entry.Time = millis(total)
+		entry.Timings = HARTimings{Send: 0, Wait: millis(wait), Receive: millis(total - wait)}
+		h.add(entry)
+	}}
+
+	return resp, nil
+}
+
+// harBody 读取时最多保存 limit 字节的请求体或返回内容
+type harBody struct {
+	io.ReadCloser
+	limit  int
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	size   int64
+	once   sync.Once
+	finish func(captured []byte, size int64, err error)
+}
+
+// Read 读取并保存内容，读取结束时生成记录
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	b.size += int64(n)
+	if remain := b.limit - b.buf.Len(); remain > 0 {
+		b.buf.Write(p[:min(n, remain)])
+	}
+	b.mu.Unlock()
+	switch {
+	case err == io.EOF:
+		b.done(nil)
+	case err != nil:
+		b.done(err)
+	}
+
+	return n, err
+}
+
+// Close 关闭内容，没有读取完时按已读取的内容生成记录
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done(nil)
+
+	return err
+}
+
+// done 生成记录，只执行一次
+func (b *harBody) done(err error) {
+	b.once.Do(func() {
+		if b.finish != nil {
+			captured, size := b.captured()
+			b.finish(captured, size, err)
+		}
+	})
+}
+
+// captured 已保存的内容与已读取的字节数
+func (b *harBody) captured() ([]byte, int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return bytes.Clone(b.buf.Bytes()), b.size
+}
+
+// Entries 已记录的请求
+func (h *HARRecorder) Entries() []HAREntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]HAREntry(nil), h.entries...)
+}
+
+// Reset 清空记录
+func (h *HARRecorder) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = nil
+}
+
+// HAR 生成 HAR 内容
+func (h *HARRecorder) HAR() HAR {
+	entries := h.Entries()
+	if entries == nil {
+		entries = []HAREntry{}
+	}
+
+	return HAR{Log: HARLog{
+		Version: harVersion,
+		Creator: HARCreator{Name: tracerName, Version: harVersion},
+		Entries: entries,
+	}}
+}
+
+// WriteFile 将记录写入 HAR 文件
+func (h *HARRecorder) WriteFile(path string) error {
+	data, err := json.MarshalIndent(h.HAR(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// add 添加记录，超过 MaxEntries 时丢弃最早的记录
+func (h *HARRecorder) add(entry HAREntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = append(h.entries, entry)
+	if h.conf.MaxEntries > 0 && len(h.entries) > h.conf.MaxEntries {
+		h.entries = h.entries[len(h.entries)-h.conf.MaxEntries:]
+	}
+}
+
+// request 生成脱敏后的请求记录，body 为发送时记录的请求体，与返回内容一样截断的 json 在配置了字段或路径脱敏时不记录
+func (h *HARRecorder) request(req *http.Request, reqBody *harBody) HARRequest {
+	var (
+		body []byte
+		size int64
+	)
+	if reqBody != nil {
+		body, size = reqBody.captured()
+	}
+	redactedURL := h.conf.Redactor.url(req.URL.String())
+	record := HARRequest{
+		Method:      req.Method,
+		URL:         redactedURL,
+		HTTPVersion: httpVersion(req.Proto),
+		Cookies:     []HARNameValue{},
+		Headers:     h.headers(req.Header),
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    int(size),
+	}
+	if u, err := req.URL.Parse(redactedURL); err == nil {
+		for k, vs := range u.Query() {
+			for _, v := range vs {
+				record.QueryString = append(record.QueryString, HARNameValue{Name: k, Value: v})
+			}
+		}
+	}
+	if len(body) > 0 {
+		contentType := req.Header.Get("Content-Type")
+		text := h.truncate(redactBody(h.conf.Redactor, contentType, body))
+		switch {
+		case req.Header.Get("Content-Encoding") != "":
+			text = encodedBodyNote(req.Header.Get("Content-Encoding"), size)
+		case size > int64(len(body)) && (len(h.conf.Redactor.Fields) > 0 || len(h.conf.Redactor.Paths) > 0):
+			text = ""
+		}
+		record.PostData = &HARPostData{MimeType: contentType, Text: text}
+	}
+
+	return record
+}
+
+// response 生成脱敏后的返回记录，size 为实际读取的字节数，超过 MaxBodySize 的 json 无法按字段脱敏，
+// 配置了字段或路径脱敏时不记录截断的内容
+func (h *HARRecorder) response(resp *http.Response, body []byte, size int64) HARResponse {
+	text := h.conf.Redactor.body(body)
+	if size > int64(len(body)) && (len(h.conf.Redactor.Fields) > 0 || len(h.conf.Redactor.Paths) > 0) {
+		text = ""
+	}
+
+	return HARResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: httpVersion(resp.Proto),
+		Cookies:     []HARNameValue{},
+		Headers:     h.headers(resp.Header),
+		Content: HARContent{
+			Size:     int(size),
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     h.truncate(text),
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    int(size),
+	}
+}
+
+// headers 请求头与返回头脱敏
+func (h *HARRecorder) headers(header http.Header) []HARNameValue {
+	flat := make(map[string]string, len(header))
+	for k, vs := range header {
+		flat[k] = strings.Join(vs, ", ")
+	}
+	pairs := make([]HARNameValue, 0, len(flat))
+	for k, v := range h.conf.Redactor.header(flat) {
+		pairs = append(pairs, HARNameValue{Name: k, Value: v})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+
+	return pairs
+}
+
+// truncate 按 MaxBodySize 截断内容
+func (h *HARRecorder) truncate(s string) string {
+	if h.conf.MaxBodySize <= 0 || len(s) <= h.conf.MaxBodySize {
+		return s
+	}
+
+	return s[:h.conf.MaxBodySize]
+}
+
+// httpVersion 协议版本，未设置时为 HTTP/1.1
+func httpVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+
+	return proto
+}
+
+// millis 转换为毫秒
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
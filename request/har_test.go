@@ -0,0 +1,137 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试记录请求与返回并导出 HAR 文件
+func TestHARRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ApplicationJson)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1,"secret":"s"}`))
+	}))
+	defer server.Close()
+
+	recorder := NewHARRecorder(HARConf{Redactor: &Redactor{Fields: []string{"secret", "password"}}, MaxEntries: 2})
+	client := NewClient(WithTransport(recorder), WithLogger(&memoryLogger{}))
+	for i := 0; i < 3; i++ {
+		_, err := client.Post(context.Background(), server.URL+"/users?page=1", map[string]any{"name": "tom", "password": "p"},
+			map[string]string{"Content-Type": ApplicationJson, "Authorization": "Bearer abc"})
+		if err != nil {
+			t.Fatalf("Post() error = %v", err)
+		}
+	}
+	_, _ = client.Get(context.Background(), "http://127.0.0.1:1/unreachable", nil, nil)
+
+	entries := recorder.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("记录 %d 条; want 2", len(entries))
+	}
+	entry := entries[0]
+	if entry.Request.Method != http.MethodPost || entry.Response.Status != http.StatusCreated || entry.Response.StatusText != "Created" {
+		t.Errorf("记录内容错误：%+v", entry)
+	}
+	if entry.Request.PostData == nil || strings.Contains(entry.Request.PostData.Text, `"p"`) || strings.Contains(entry.Response.Content.Text, `"s"`) {
+		t.Errorf("请求体或返回内容未脱敏")
+	}
+	if len(entry.Request.QueryString) != 1 || entry.Request.QueryString[0].Value != "1" {
+		t.Errorf("QueryString = %+v", entry.Request.QueryString)
+	}
+	if entries[1].Comment == "" {
+		t.Errorf("请求失败时应记录错误信息")
+	}
+
+	path := filepath.Join(t.TempDir(), "requests.har")
+	if err := recorder.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "Bearer abc") {
+		t.Errorf("HAR 文件中包含 Authorization")
+	}
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil || har.Log.Version != "1.2" || len(har.Log.Entries) != 2 {
+		t.Errorf("HAR 文件内容错误：%v", err)
+	}
+
+	recorder.Reset()
+	if len(recorder.HAR().Log.Entries) != 0 {
+		t.Errorf("Reset() 后记录未清空")
+	}
+}
+
+// 测试返回内容边读取边记录，不绕过客户端的最大长度限制，事件流不记录内容
+func TestHARRecorder_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: ok\n\n"))
+			return
+		}
+		_, _ = w.Write([]byte(strings.Repeat("a", 1000)))
+	}))
+	defer server.Close()
+
+	recorder := NewHARRecorder(HARConf{MaxBodySize: 100})
+	client := NewClient(WithTransport(recorder), WithMaxBodySize(10), WithLogger(&memoryLogger{}))
+	if _, err := client.Get(context.Background(), server.URL, nil, nil); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Get() error = %v; want ErrBodyTooLarge", err)
+	}
+
+	var data string
+	err := client.Subscribe(context.Background(), server.URL+"/events", &SSEOptions{DisableReconnect: true}, func(event Event) error {
+		data = event.Data
+		return nil
+	})
+	if err != nil || data != "ok" {
+		t.Errorf("Subscribe() data = %q, error = %v", data, err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("记录 %d 条; want 2", len(entries))
+	}
+	if content := entries[0].Response.Content; content.Size != 11 || content.Text != strings.Repeat("a", 11) {
+		t.Errorf("返回内容记录 size = %d, text = %q", content.Size, content.Text)
+	}
+	if entries[1].Response.Content.Text != "" || entries[1].Comment == "" {
+		t.Errorf("事件流记录 = %+v", entries[1])
+	}
+}
+
+// 测试请求体在发送时记录，流式压缩的请求体不提前读取，记录中使用说明代替压缩内容
+func TestHARRecorder_RequestBody(t *testing.T) {
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = len(data)
+	}))
+	defer server.Close()
+
+	recorder := NewHARRecorder(HARConf{MaxBodySize: 10})
+	client := NewClient(WithTransport(recorder), WithLogger(&memoryLogger{}), WithRequestCompression(CompressConf{Stream: true}))
+	_, err := client.Post(context.Background(), server.URL, map[string]any{"data": strings.Repeat("a", 100)},
+		map[string]string{"Content-Type": ApplicationJson})
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 || entries[0].Request.PostData == nil {
+		t.Fatalf("记录 = %+v", entries)
+	}
+	request := entries[0].Request
+	if request.BodySize != received || !strings.Contains(request.PostData.Text, "gzip") {
+		t.Errorf("请求体记录 size = %d, text = %q; want size %d", request.BodySize, request.PostData.Text, received)
+	}
+}
@@ -0,0 +1,91 @@
+package request
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"time"
+)
+
+// HedgeConf 对冲请求配置，get、head 请求在 Delay 内没有返回时再发出一个相同的请求，使用最先成功的返回并取消其余请求
+type HedgeConf struct {
+	// Delay 发出下一个请求前的等待时间，一般设置为接口耗时的 P95
+	Delay time.Duration
+	// MaxAttempts 最多发出的请求数（包含首次请求），小于 2 时不对冲
+	MaxAttempts int
+}
+
+// WithHedging 开启对冲请求，用于降低延迟敏感的读接口的长尾耗时，每一次重试都会单独对冲
+func WithHedging(conf HedgeConf) Option {
+	return func(c *Client) {
+		if conf.MaxAttempts < 2 || conf.Delay <= 0 {
+			c.hedging = nil
+			return
+		}
+		c.hedging = &conf
+	}
+}
+
+// hedgeResult 单个对冲请求的结果
+type hedgeResult struct {
+	attempt int
+	resp    *Response
+	err     error
+}
+
+// hedge 发送对冲请求，所有请求共享 ctx，返回后取消尚未完成的请求
+func (c *Client) hedge(ctx context.Context, req *http.Request) (*Response, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 缓冲区可以容纳所有结果，被取消的请求不会阻塞
+	results := make(chan hedgeResult, c.hedging.MaxAttempts)
+	launch := func(attempt int) {
+		go func() {
+			resp, err := c.exchange(ctx, req.Clone(ctx))
+			results <- hedgeResult{attempt: attempt, resp: resp, err: err}
+		}()
+	}
+	launch(1)
+	sent, pending := 1, 1
+
+	timer := time.NewTimer(c.hedging.Delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if sent < c.hedging.MaxAttempts {
+				sent++
+				pending++
+				launch(sent)
+				timer.Reset(c.hedging.Delay)
+			}
+		case result := <-results:
+			pending--
+			success := result.err == nil && result.resp.StatusCode < http.StatusInternalServerError
+			// 所有请求都失败时返回最后一个结果，由重试策略决定是否重试
+			if success || pending == 0 {
+				c.reportHedge(ctx, sent, result, success)
+				return result.resp, result.err
+			}
+		}
+	}
+}
+
+// reportHedge 在日志与 span 中记录对冲请求数与胜出的请求
+func (c *Client) reportHedge(ctx context.Context, sent int, result hedgeResult, success bool) {
+	if sent < 2 {
+		return
+	}
+	trace.SpanFromContext(ctx).AddEvent("hedge", trace.WithAttributes(
+		attribute.Int("http.hedge.sent", sent),
+		attribute.Int("http.hedge.winner", result.attempt),
+		attribute.Bool("http.hedge.success", success),
+	))
+	if !success {
+		c.logger.Errorf(ctx, "对冲请求共发出%d个，全部失败", sent)
+		return
+	}
+	c.logger.Infof(ctx, "对冲请求共发出%d个，第%d个请求最先返回", sent, result.attempt)
+}
@@ -0,0 +1,84 @@
+package request
+
+import (
+	"context"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试首个请求超过 Delay 未返回时发出对冲请求，使用最先返回的结果并取消其余请求
+func TestWithHedging(t *testing.T) {
+	var count int32
+	canceled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+				canceled <- struct{}{}
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("fast"))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	logger := &memoryLogger{}
+	client := NewClient(
+		WithHedging(HedgeConf{Delay: 20 * time.Millisecond, MaxAttempts: 3}),
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		WithLogger(logger),
+	)
+	start := time.Now()
+	body, err := client.Get(context.Background(), server.URL, nil, nil)
+	if err != nil || string(body) != "fast" {
+		t.Fatalf("Get() = %q, %v", body, err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("对冲请求未生效，耗时 %s", time.Since(start))
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Errorf("较慢的请求未被取消")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || len(spans[0].Events()) != 1 || spans[0].Events()[0].Name != "hedge" {
+		t.Fatalf("span 中没有对冲事件")
+	}
+	for _, kv := range spans[0].Events()[0].Attributes {
+		if kv.Key == "http.hedge.winner" && kv.Value.AsInt64() != 2 {
+			t.Errorf("http.hedge.winner = %d; want 2", kv.Value.AsInt64())
+		}
+	}
+	if !strings.Contains(logger.String(), "第2个请求最先返回") {
+		t.Errorf("日志中没有记录胜出的请求")
+	}
+}
+
+// 测试请求在 Delay 内返回时不发出对冲请求，post 请求不对冲
+func TestWithHedging_Fast(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		if r.Method == http.MethodPost {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(WithHedging(HedgeConf{Delay: 20 * time.Millisecond, MaxAttempts: 2}))
+	_, _ = client.Get(context.Background(), server.URL, nil, nil)
+	_, _ = client.Post(context.Background(), server.URL, nil, nil)
+	if count != 2 {
+		t.Errorf("实际请求 %d 次; want 2", count)
+	}
+}
@@ -0,0 +1,102 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxSnippetSize DecodeError 中保留的返回内容片段最大字节数
+const maxSnippetSize = 256
+
+// DecodeError 返回内容json解析失败的错误
+type DecodeError struct {
+	// Err 原始解析错误
+	Err error
+	// Snippet 解析出错位置附近的返回内容，可能包含敏感信息，不会出现在 Error() 中
+	Snippet string
+}
+
+// Error 实现 error 接口，不包含返回内容片段，避免错误日志绕过脱敏配置
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("返回数据json解析失败：%s", e.Err)
+}
+
+// Unwrap 返回原始解析错误
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DoJSON 以json格式发起请求，并将返回内容解析为 T，c 为空时使用默认客户端
+// get、head、options 请求时 reqData 作为查询参数，其余请求时 reqData 可以是任意可json序列化的结构体
+func DoJSON[T any](ctx context.Context, c *Client, method, reqUrl string, reqData any, header map[string]string) (T, error) {
+	var result T
+	if c == nil {
+		c = defaultClient
+	}
+
+	jsonHeader := map[string]string{"Accept": ApplicationJson}
+	if hasBody(strings.ToUpper(method)) {
+		jsonHeader["Content-Type"] = ApplicationJson
+	}
+	for k, v := range header {
+		jsonHeader[k] = v
+	}
+
+	resp, err := c.Do(ctx, method, reqUrl, reqData, jsonHeader)
+	if err != nil {
+		return result, err
+	}
+	if err = decodeJSON(resp.Body, &result); err != nil {
+		c.logger.Errorf(ctx, "%s", err)
+		return result, err
+	}
+
+	return result, nil
+}
+
+// GetJSON 发起get请求，并将返回内容解析为 T
+func GetJSON[T any](ctx context.Context, c *Client, reqUrl string, reqData any, header map[string]string) (T, error) {
+	return DoJSON[T](ctx, c, http.MethodGet, reqUrl, reqData, header)
+}
+
+// PostJSON 以json格式发起post请求，并将返回内容解析为 T
+func PostJSON[T any](ctx context.Context, c *Client, reqUrl string, reqData any, header map[string]string) (T, error) {
+	return DoJSON[T](ctx, c, http.MethodPost, reqUrl, reqData, header)
+}
+
+// decodeJSON 解析json，失败时返回携带出错片段的 DecodeError
+func decodeJSON(data []byte, v any) error {
+	err := json.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	} else if errors.As(err, &typeErr) {
+		offset = typeErr.Offset
+	}
+
+	return &DecodeError{Err: err, Snippet: snippet(data, offset)}
+}
+
+// snippet 截取 offset 附近的内容
+func snippet(data []byte, offset int64) string {
+	start := offset - maxSnippetSize/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + maxSnippetSize
+	if end > int64(len(data)) {
+		end = int64(len(data))
+	}
+
+	return string(data[start:end])
+}
@@ -0,0 +1,73 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type jsonUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// 测试 PostJSON 发送结构体并解析返回内容
+func TestPostJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var user jsonUser
+		if r.Header.Get("Content-Type") != ApplicationJson {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&user)
+		user.ID = 10
+		_ = json.NewEncoder(w).Encode(user)
+	}))
+	defer server.Close()
+
+	user, err := PostJSON[jsonUser](context.Background(), NewClient(), server.URL, jsonUser{Name: "tom"}, nil)
+	if err != nil {
+		t.Fatalf("PostJSON() error = %v", err)
+	}
+	if user.ID != 10 || user.Name != "tom" {
+		t.Errorf("PostJSON() = %+v; want {ID:10 Name:tom}", user)
+	}
+}
+
+// 测试 GetJSON 使用默认客户端
+func TestGetJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"id":1,"name":"` + r.URL.Query().Get("name") + `"}]`))
+	}))
+	defer server.Close()
+
+	users, err := GetJSON[[]jsonUser](context.Background(), nil, server.URL, map[string]any{"name": "jerry"}, nil)
+	if err != nil {
+		t.Fatalf("GetJSON() error = %v", err)
+	}
+	if len(users) != 1 || users[0].Name != "jerry" {
+		t.Errorf("GetJSON() = %+v", users)
+	}
+}
+
+// 测试解析失败时返回 DecodeError
+func TestGetJSON_DecodeError(t *testing.T) {
+	body := `{"id":"abc"}` + strings.Repeat(" ", 500)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	_, err := GetJSON[jsonUser](context.Background(), nil, server.URL, nil, nil)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("GetJSON() error = %v; want *DecodeError", err)
+	}
+	if !strings.Contains(decodeErr.Snippet, `"abc"`) || len(decodeErr.Snippet) > maxSnippetSize {
+		t.Errorf("DecodeError.Snippet = %q", decodeErr.Snippet)
+	}
+}
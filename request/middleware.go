@@ -0,0 +1,177 @@
+package request
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Handler 发送单次http请求，返回的 Response.Body 由调用方负责关闭
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Middleware 中间件，可以在调用 next 前后检查、修改请求与返回
+type Middleware func(next Handler) Handler
+
+// WithMiddleware 添加中间件，按添加顺序由外到内执行
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// chain 将中间件组装到 handler 外层
+func chain(handler Handler, middlewares []Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// HeaderMiddleware 为每个请求设置固定请求头
+func HeaderMiddleware(header map[string]string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			for k, v := range header {
+				req.Header.Set(k, v)
+			}
+			return next(req)
+		}
+	}
+}
+
+// AuthMiddleware 为每个请求设置 Authorization 请求头，token 在每次请求时获取，便于使用会过期的凭证
+func AuthMiddleware(token func(ctx context.Context) (string, error)) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			value, err := token(req.Context())
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", value)
+			return next(req)
+		}
+	}
+}
+
+// LoggingMiddleware 记录每一次实际发出的请求，包括重试
+func LoggingMiddleware(logger Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			if err != nil {
+				logger.Errorf(req.Context(), "http请求失败，%s %s，耗时：%s，错误：%v", req.Method, req.URL.Redacted(), time.Since(start), err)
+			} else {
+				logger.Infof(req.Context(), "http请求完成，%s %s，状态码：%d，耗时：%s", req.Method, req.URL.Redacted(), resp.StatusCode, time.Since(start))
+			}
+			return resp, err
+		}
+	}
+}
+
+// TracingMiddleware 为每一次实际发出的请求创建 span 并注入链路信息，tp 为空时使用全局 TracerProvider
+// 客户端已经为每次调用创建了 span，该中间件用于需要观察每次重试的场景
+func TracingMiddleware(tp trace.TracerProvider) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			provider := tp
+			if provider == nil {
+				provider = otel.GetTracerProvider()
+			}
+			ctx, span := provider.Tracer(tracerName).Start(req.Context(), "HTTP "+req.Method,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.URLFull(req.URL.Redacted()),
+					semconv.ServerAddress(req.URL.Hostname()),
+				),
+			)
+			defer span.End()
+
+			req = req.WithContext(ctx)
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+			resp, err := next(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return nil, err
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+			if resp.StatusCode >= 400 {
+				span.SetStatus(codes.Error, strconv.Itoa(resp.StatusCode))
+			}
+			return resp, nil
+		}
+	}
+}
+
+// Metrics 请求指标收集接口，可以对接 prometheus 等监控系统
+type Metrics interface {
+	// Observe 记录一次请求，statusCode 为 0 表示请求失败没有返回
+	Observe(method, host string, statusCode int, duration time.Duration, err error)
+}
+
+// MetricsMiddleware 记录每一次实际发出的请求的状态码与耗时
+func MetricsMiddleware(metrics Metrics) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			var statusCode int
+			if resp != nil {
+				statusCode = resp.StatusCode
+			}
+			metrics.Observe(req.Method, req.URL.Host, statusCode, time.Since(start), err)
+			return resp, err
+		}
+	}
+}
+
+// RetryMiddleware 按重试策略重试单次请求，与 WithRetry 不同，不会重试读取返回内容时发生的错误
+func RetryMiddleware(policy RetryPolicy) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			maxAttempts := policy.maxAttempts(req.Method)
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				maxAttempts = 1
+			}
+
+			for attempt := 1; ; attempt++ {
+				resp, err := next(req)
+				var (
+					statusCode int
+					header     http.Header
+				)
+				if resp != nil {
+					statusCode, header = resp.StatusCode, resp.Header
+				}
+				if attempt >= maxAttempts || ctx.Err() != nil || !policy.shouldRetry(statusCode, err) {
+					return resp, err
+				}
+				if resp != nil {
+					_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+					_ = resp.Body.Close()
+				}
+
+				if err = sleep(ctx, policy.delay(attempt, statusCode, header)); err != nil {
+					return nil, err
+				}
+				req = req.Clone(ctx)
+				if req.GetBody != nil {
+					if req.Body, err = req.GetBody(); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testMetrics 记录指标调用次数
+type testMetrics struct {
+	count int32
+	last  int32
+}
+
+func (m *testMetrics) Observe(method, host string, statusCode int, duration time.Duration, err error) {
+	atomic.AddInt32(&m.count, 1)
+	atomic.StoreInt32(&m.last, int32(statusCode))
+}
+
+// 测试中间件执行顺序与请求、返回修改
+func TestWithMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Order") + "|" + r.Header.Get("Authorization") + "|" + r.Header.Get("X-App")))
+	}))
+	defer server.Close()
+
+	var order []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				req.Header.Set("X-Order", strings.Join(order, ","))
+				resp, err := next(req)
+				if err == nil {
+					resp.Header.Set("X-After-"+name, "1")
+				}
+				return resp, err
+			}
+		}
+	}
+
+	metrics := &testMetrics{}
+	client := NewClient(WithMiddleware(
+		record("a"),
+		record("b"),
+		HeaderMiddleware(map[string]string{"X-App": "demo"}),
+		AuthMiddleware(func(ctx context.Context) (string, error) { return "Bearer token", nil }),
+		MetricsMiddleware(metrics),
+	))
+	resp, err := client.Do(context.Background(), http.MethodGet, server.URL, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if string(resp.Body) != "a,b|Bearer token|demo" {
+		t.Errorf("Do() = %q; want %q", resp.Body, "a,b|Bearer token|demo")
+	}
+	if resp.Header.Get("X-After-a") != "1" || resp.Header.Get("X-After-b") != "1" {
+		t.Errorf("中间件未能修改返回头：%v", resp.Header)
+	}
+	if atomic.LoadInt32(&metrics.count) != 1 || atomic.LoadInt32(&metrics.last) != http.StatusOK {
+		t.Errorf("MetricsMiddleware 记录次数 = %d，状态码 = %d", metrics.count, metrics.last)
+	}
+}
+
+// 测试重试中间件，每次重试都会经过内层中间件
+func TestRetryMiddleware(t *testing.T) {
+	server, count := failingServer(2, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	metrics := &testMetrics{}
+	client := NewClient(WithMiddleware(RetryMiddleware(policy), MetricsMiddleware(metrics)))
+	body, err := client.Get(context.Background(), server.URL, nil, nil)
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("Get() = %q, %v", body, err)
+	}
+	if atomic.LoadInt32(count) != 3 || atomic.LoadInt32(&metrics.count) != 3 {
+		t.Errorf("请求次数 = %d，指标记录次数 = %d; want 3, 3", *count, metrics.count)
+	}
+}
@@ -0,0 +1,159 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MultipartForm multipart/form-data 请求体，作为 reqData 传入 Post、Put 等请求时生效
+// 请求体通过 io.Pipe 边编码边发送，文件内容不会整体读入内存，因此该请求不会被重试
+type MultipartForm struct {
+	// Fields 普通表单字段
+	Fields map[string]string
+	// Files 文件字段
+	Files []FilePart
+	// Progress 上传进度回调，written 为已发送的字节数
+	Progress func(written int64)
+}
+
+// FilePart multipart 中的文件字段，Reader、Path、Data 三选一
+type FilePart struct {
+	// FieldName 表单字段名
+	FieldName string
+	// FileName 文件名，为空时使用 Path 的文件名
+	FileName string
+	// ContentType 文件类型，为空时为 application/octet-stream
+	ContentType string
+	// Reader 文件内容
+	Reader io.Reader
+	// Path 文件路径
+	Path string
+	// Data 文件内容
+	Data []byte
+}
+
+// String 日志输出时只展示字段名与文件名
+func (f *MultipartForm) String() string {
+	files := make([]string, 0, len(f.Files))
+	for _, file := range f.Files {
+		files = append(files, file.FieldName+"="+file.fileName())
+	}
+
+	return fmt.Sprintf("multipart{fields:%v files:%v}", f.Fields, files)
+}
+
+// Upload 以 multipart/form-data 格式发起post请求
+func (c *Client) Upload(ctx context.Context, reqUrl string, form *MultipartForm, header map[string]string) (*Response, error) {
+	return c.Do(ctx, http.MethodPost, reqUrl, form, header)
+}
+
+// reader 返回边编码边读取的请求体及对应的 Content-Type
+func (f *MultipartForm) reader(ctx context.Context) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		err := f.write(ctx, writer)
+		if err == nil {
+			err = writer.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	var body io.ReadCloser = pr
+	if f.Progress != nil {
+		body = &progressReader{ReadCloser: pr, progress: f.Progress}
+	}
+
+	return body, writer.FormDataContentType()
+}
+
+// write 依次写入表单字段与文件
+func (f *MultipartForm) write(ctx context.Context, writer *multipart.Writer) error {
+	for k, v := range f.Fields {
+		if err := writer.WriteField(k, v); err != nil {
+			return err
+		}
+	}
+	for _, file := range f.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := file.write(writer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write 写入单个文件
+func (p FilePart) write(writer *multipart.Writer) error {
+	contentType := p.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(p.FieldName), escapeQuotes(p.fileName())))
+	h.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case p.Reader != nil:
+		_, err = io.Copy(part, p.Reader)
+	case p.Path != "":
+		var file *os.File
+		if file, err = os.Open(p.Path); err != nil {
+			return err
+		}
+		_, err = io.Copy(part, file)
+		_ = file.Close()
+	default:
+		_, err = part.Write(p.Data)
+	}
+
+	return err
+}
+
+// fileName 文件名
+func (p FilePart) fileName() string {
+	if p.FileName == "" && p.Path != "" {
+		return filepath.Base(p.Path)
+	}
+
+	return p.FileName
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes 转义 Content-Disposition 中的引号
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// progressReader 统计已读取字节数并回调
+type progressReader struct {
+	io.ReadCloser
+	written  int64
+	progress func(written int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.written += int64(n)
+		r.progress(r.written)
+	}
+
+	return n, err
+}
@@ -0,0 +1,76 @@
+package request
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试 multipart 上传表单字段与多种来源的文件
+func TestClient_Upload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var result []string
+		result = append(result, "name="+r.FormValue("name"))
+		for _, field := range []string{"reader", "path", "bytes"} {
+			file, header, err := r.FormFile(field)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			b, _ := io.ReadAll(file)
+			result = append(result, field+"="+header.Filename+":"+header.Header.Get("Content-Type")+":"+string(b))
+		}
+		_, _ = w.Write([]byte(strings.Join(result, "\n")))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte("a,b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var written int64
+	form := &MultipartForm{
+		Fields: map[string]string{"name": "tom"},
+		Files: []FilePart{
+			{FieldName: "reader", FileName: "a.txt", ContentType: "text/plain", Reader: strings.NewReader("hello")},
+			{FieldName: "path", Path: path, ContentType: "text/csv"},
+			{FieldName: "bytes", FileName: "b.png", ContentType: "image/png", Data: []byte("png")},
+		},
+		Progress: func(n int64) { written = n },
+	}
+	resp, err := NewClient().Upload(context.Background(), server.URL, form, nil)
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	want := "name=tom\nreader=a.txt:text/plain:hello\npath=report.csv:text/csv:a,b\nbytes=b.png:image/png:png"
+	if string(resp.Body) != want {
+		t.Errorf("Upload() = %q; want %q", resp.Body, want)
+	}
+	if written == 0 {
+		t.Errorf("Progress 回调未触发")
+	}
+}
+
+// 测试文件不存在时返回错误
+func TestClient_UploadFileNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	form := &MultipartForm{Files: []FilePart{{FieldName: "file", Path: "/not/exist"}}}
+	if _, err := NewClient().Upload(context.Background(), server.URL, form, nil); err == nil {
+		t.Errorf("Upload() 期望返回文件不存在的错误")
+	}
+}
@@ -0,0 +1,285 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRateLimited 请求频率或并发数超过限制，请求未发出
+var ErrRateLimited = errors.New("请求频率超过限制")
+
+// clientScope 客户端级别限流的统计键
+const clientScope = "*"
+
+// RateLimit 令牌桶限流规则，Per 时间内最多 Requests 个请求
+type RateLimit struct {
+	// Requests 时间窗口内允许的请求数
+	Requests int
+	// Per 时间窗口
+	Per time.Duration
+	// Burst 令牌桶容量，允许的瞬时请求数，0 表示等于 Requests
+	Burst int
+}
+
+// RateLimitConf 限流配置
+type RateLimitConf struct {
+	// Limits 限流规则，需要同时满足，例如每秒 5 个且每分钟 100 个
+	Limits []RateLimit
+	// MaxInFlight 最大并发请求数，0 表示不限制
+	MaxInFlight int
+	// Wait 超过限制时是否等待，true 时等待直到允许或 ctx 结束，false 时立即返回 ErrRateLimited
+	Wait bool
+}
+
+// RateLimitStats 限流统计
+type RateLimitStats struct {
+	// Allowed 允许通过的请求数
+	Allowed int64
+	// Rejected 被拒绝的请求数，包括等待时 ctx 结束的请求
+	Rejected int64
+	// Waited 经过等待后通过的请求数
+	Waited int64
+	// InFlight 当前并发请求数
+	InFlight int64
+}
+
+// WithRateLimit 设置客户端级别的限流，对所有主机的请求生效
+func WithRateLimit(conf RateLimitConf) Option {
+	return func(c *Client) {
+		c.rateLimiters().scopes[clientScope] = newRateLimiter(conf)
+	}
+}
+
+// WithHostRateLimit 设置指定主机的限流，host 包含端口时需要与请求地址一致，与客户端级别的限流同时生效
+func WithHostRateLimit(host string, conf RateLimitConf) Option {
+	return func(c *Client) {
+		c.rateLimiters().scopes[host] = newRateLimiter(conf)
+	}
+}
+
+// RateLimitStats 获取限流统计，键为主机名，客户端级别的统计键为 *
+func (c *Client) RateLimitStats() map[string]RateLimitStats {
+	stats := make(map[string]RateLimitStats)
+	if c.limiters == nil {
+		return stats
+	}
+	for scope, limiter := range c.limiters.scopes {
+		stats[scope] = limiter.stats()
+	}
+
+	return stats
+}
+
+// rateLimiters 获取限流器集合，不存在时创建
+func (c *Client) rateLimiters() *limiterGroup {
+	if c.limiters == nil {
+		c.limiters = &limiterGroup{scopes: make(map[string]*rateLimiter)}
+	}
+
+	return c.limiters
+}
+
+// limiterGroup 客户端级别与主机级别的限流器，创建客户端后只读
+type limiterGroup struct {
+	scopes map[string]*rateLimiter
+}
+
+// middleware 限流中间件，并发名额在返回内容关闭后释放，流式读取的下载与订阅同样计入并发数
+func (g *limiterGroup) middleware(next Handler) Handler {
+	return func(req *http.Request) (*http.Response, error) {
+		var releases []func()
+		release := func() {
+			for _, r := range releases {
+				r()
+			}
+		}
+		for _, scope := range []string{clientScope, req.URL.Host} {
+			limiter, ok := g.scopes[scope]
+			if !ok {
+				continue
+			}
+			r, err := limiter.acquire(req.Context())
+			if err != nil {
+				release()
+				return nil, fmt.Errorf("%w：%s", err, req.URL.Host)
+			}
+			releases = append(releases, r)
+		}
+
+		resp, err := next(req)
+		if err != nil || resp.Body == nil {
+			release()
+			return resp, err
+		}
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+
+		return resp, nil
+	}
+}
+
+// releaseBody 关闭时释放并发名额的返回内容
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close 关闭返回内容并释放并发名额
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+
+	return err
+}
+
+// rateLimiter 令牌桶与并发数限制
+type rateLimiter struct {
+	mu       sync.Mutex
+	buckets  []*tokenBucket
+	sem      chan struct{}
+	wait     bool
+	allowed  int64
+	rejected int64
+	waited   int64
+	inFlight int64
+}
+
+// newRateLimiter 初始化限流器
+func newRateLimiter(conf RateLimitConf) *rateLimiter {
+	l := &rateLimiter{wait: conf.Wait}
+	if conf.MaxInFlight > 0 {
+		l.sem = make(chan struct{}, conf.MaxInFlight)
+	}
+	now := time.Now()
+	for _, limit := range conf.Limits {
+		if limit.Requests <= 0 || limit.Per <= 0 {
+			continue
+		}
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = limit.Requests
+		}
+		l.buckets = append(l.buckets, &tokenBucket{
+			rate:     float64(limit.Requests) / limit.Per.Seconds(),
+			capacity: float64(burst),
+			tokens:   float64(burst),
+			last:     now,
+		})
+	}
+
+	return l
+}
+
+// acquire 获取并发名额与令牌，返回释放并发名额的函数
+func (l *rateLimiter) acquire(ctx context.Context) (func(), error) {
+	var waited bool
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		default:
+			if !l.wait {
+				atomic.AddInt64(&l.rejected, 1)
+				return nil, ErrRateLimited
+			}
+			waited = true
+			select {
+			case l.sem <- struct{}{}:
+			case <-ctx.Done():
+				atomic.AddInt64(&l.rejected, 1)
+				return nil, ctx.Err()
+			}
+		}
+	}
+	release := func() {
+		if l.sem != nil {
+			<-l.sem
+		}
+		atomic.AddInt64(&l.inFlight, -1)
+	}
+	atomic.AddInt64(&l.inFlight, 1)
+
+	for {
+		delay := l.take()
+		if delay == 0 {
+			break
+		}
+		if !l.wait {
+			release()
+			atomic.AddInt64(&l.rejected, 1)
+			return nil, ErrRateLimited
+		}
+		waited = true
+		if err := sleep(ctx, delay); err != nil {
+			release()
+			atomic.AddInt64(&l.rejected, 1)
+			return nil, err
+		}
+	}
+
+	atomic.AddInt64(&l.allowed, 1)
+	if waited {
+		atomic.AddInt64(&l.waited, 1)
+	}
+
+	return release, nil
+}
+
+// take 所有令牌桶都有令牌时各取一个并返回 0，否则返回需要等待的时间
+func (l *rateLimiter) take() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	var delay time.Duration
+	for _, b := range l.buckets {
+		if d := b.wait(now); d > delay {
+			delay = d
+		}
+	}
+	if delay > 0 {
+		return delay
+	}
+	for _, b := range l.buckets {
+		b.tokens--
+	}
+
+	return 0
+}
+
+// stats 限流统计
+func (l *rateLimiter) stats() RateLimitStats {
+	return RateLimitStats{
+		Allowed:  atomic.LoadInt64(&l.allowed),
+		Rejected: atomic.LoadInt64(&l.rejected),
+		Waited:   atomic.LoadInt64(&l.waited),
+		InFlight: atomic.LoadInt64(&l.inFlight),
+	}
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+// wait 补充令牌后返回获取一个令牌需要等待的时间
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
@@ -0,0 +1,149 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试超过频率限制时立即返回 ErrRateLimited
+func TestWithRateLimit_Reject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := NewClient(WithRateLimit(RateLimitConf{Limits: []RateLimit{{Requests: 2, Per: time.Minute}}}))
+	for i := 0; i < 2; i++ {
+		if _, err := client.Get(context.Background(), server.URL, nil, nil); err != nil {
+			t.Fatalf("第%d次请求 error = %v", i+1, err)
+		}
+	}
+	if _, err := client.Get(context.Background(), server.URL, nil, nil); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("超过限制 error = %v; want ErrRateLimited", err)
+	}
+	stats := client.RateLimitStats()[clientScope]
+	if stats.Allowed != 2 || stats.Rejected != 1 {
+		t.Errorf("RateLimitStats() = %+v", stats)
+	}
+}
+
+// 测试主机级别的限流等待，ctx 结束时停止等待
+func TestWithHostRateLimit_Wait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	client := NewClient(WithHostRateLimit(u.Host, RateLimitConf{Limits: []RateLimit{{Requests: 10, Per: time.Second, Burst: 1}}, Wait: true}))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := client.Get(context.Background(), server.URL, nil, nil); err != nil {
+			t.Fatalf("第%d次请求 error = %v", i+1, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("等待时间 = %s; want >= 200ms", elapsed)
+	}
+	if stats := client.RateLimitStats()[u.Host]; stats.Waited != 2 {
+		t.Errorf("RateLimitStats() = %+v", stats)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	client = NewClient(WithRateLimit(RateLimitConf{Limits: []RateLimit{{Requests: 1, Per: time.Minute}}, Wait: true}))
+	_, _ = client.Get(ctx, server.URL, nil, nil)
+	if _, err := client.Get(ctx, server.URL, nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ctx 结束后 error = %v; want context.DeadlineExceeded", err)
+	}
+}
+
+// 测试最大并发数
+func TestWithRateLimit_MaxInFlight(t *testing.T) {
+	var current, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithRateLimit(RateLimitConf{MaxInFlight: 2, Wait: true}))
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = client.Get(context.Background(), server.URL, nil, nil)
+		}()
+	}
+	wg.Wait()
+	if atomic.LoadInt32(&peak) > 2 {
+		t.Errorf("最大并发数 = %d; want <= 2", peak)
+	}
+	if stats := client.RateLimitStats()[clientScope]; stats.Allowed != 6 || stats.InFlight != 0 {
+		t.Errorf("RateLimitStats() = %+v", stats)
+	}
+}
+
+// signalWriter 首次写入时发出通知
+type signalWriter struct {
+	once    sync.Once
+	written chan struct{}
+}
+
+// Write 写入时通知
+func (w *signalWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.written) })
+	return len(p), nil
+}
+
+// 测试流式下载在读取返回内容期间占用并发名额，关闭后释放
+func TestWithRateLimit_MaxInFlightStream(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stream" {
+			return
+		}
+		_, _ = w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		<-unblock
+		_, _ = w.Write([]byte("last"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithRateLimit(RateLimitConf{MaxInFlight: 1}))
+	w := &signalWriter{written: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Download(context.Background(), server.URL+"/stream", w, nil)
+		done <- err
+	}()
+	<-w.written
+
+	if stats := client.RateLimitStats()[clientScope]; stats.InFlight != 1 {
+		t.Errorf("下载期间 InFlight = %d; want 1", stats.InFlight)
+	}
+	if _, err := client.Get(context.Background(), server.URL, nil, nil); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("下载期间 error = %v; want ErrRateLimited", err)
+	}
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if _, err := client.Get(context.Background(), server.URL, nil, nil); err != nil {
+		t.Errorf("下载结束后 error = %v", err)
+	}
+	if stats := client.RateLimitStats()[clientScope]; stats.InFlight != 0 {
+		t.Errorf("RateLimitStats() = %+v", stats)
+	}
+}
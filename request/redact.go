@@ -0,0 +1,206 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// redactedValue 脱敏后的替换内容
+const redactedValue = "******"
+
+// LogLevel 请求日志级别
+type LogLevel int
+
+const (
+	// LogLevelFull 记录脱敏后的请求参数、请求头与返回内容，默认级别
+	LogLevelFull LogLevel = iota
+	// LogLevelSummary 只记录请求方法、地址、状态码与耗时
+	LogLevelSummary
+	// LogLevelOff 不记录请求日志
+	LogLevelOff
+)
+
+// defaultRedactHeaders 默认脱敏的请求头
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// Redactor 日志脱敏配置
+type Redactor struct {
+	// Headers 需要脱敏的请求头，不区分大小写，为空时使用 Authorization、Cookie 等默认请求头
+	Headers []string
+	// Fields 需要脱敏的字段名，不区分大小写，作用于任意层级的请求参数、json 内容与查询参数
+	Fields []string
+	// Paths 需要脱敏的 json 路径，以 . 分隔，* 匹配任意字段名或数组下标，如 data.users.*.phone
+	Paths []string
+	// MaxBodySize 日志中请求参数与返回内容的最大长度，超出部分截断，0 表示不限制
+	MaxBodySize int
+}
+
+// WithLogLevel 设置请求日志级别
+func WithLogLevel(level LogLevel) Option {
+	return func(c *Client) {
+		c.logLevel = level
+	}
+}
+
+// WithRedactor 设置日志脱敏配置
+func WithRedactor(r Redactor) Option {
+	return func(c *Client) {
+		c.redactor = &r
+	}
+}
+
+// header 请求头脱敏
+func (r *Redactor) header(header map[string]string) map[string]string {
+	names := r.Headers
+	if len(names) == 0 {
+		names = defaultRedactHeaders
+	}
+
+	redacted := make(map[string]string, len(header))
+	for k, v := range header {
+		redacted[k] = v
+		for _, name := range names {
+			if strings.EqualFold(k, name) {
+				redacted[k] = redactedValue
+				break
+			}
+		}
+	}
+
+	return redacted
+}
+
+// url 查询参数脱敏，同时隐藏地址中的用户名密码
+func (r *Redactor) url(reqUrl string) string {
+	u, err := url.Parse(reqUrl)
+	if err != nil {
+		return reqUrl
+	}
+	if len(r.Fields) > 0 && u.RawQuery != "" {
+		query := u.Query()
+		for k := range query {
+			if r.isField(k) {
+				query[k] = []string{redactedValue}
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	return u.Redacted()
+}
+
+// error 错误信息脱敏，StatusError 中的请求地址按查询参数脱敏
+func (r *Redactor) error(err error) string {
+	msg := err.Error()
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.URL != "" {
+		msg = strings.ReplaceAll(msg, statusErr.URL, r.url(statusErr.URL))
+	}
+
+	return msg
+}
+
+// data 请求参数脱敏，返回可直接输出的字符串
+func (r *Redactor) data(reqData any) string {
+	if s, ok := reqData.(fmt.Stringer); ok {
+		return r.truncate(s.String())
+	}
+	b, err := json.Marshal(reqData)
+	if err != nil {
+		return r.truncate(fmt.Sprintf("%+v", reqData))
+	}
+
+	return r.body(b)
+}
+
+// body json 内容按字段与路径脱敏，非 json 内容只做截断
+func (r *Redactor) body(body []byte) string {
+	if len(r.Fields) == 0 && len(r.Paths) == 0 {
+		return r.truncate(string(body))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return r.truncate(string(body))
+	}
+	b, err := json.Marshal(r.walk(v, nil))
+	if err != nil {
+		return r.truncate(string(body))
+	}
+
+	return r.truncate(string(b))
+}
+
+// walk 递归替换需要脱敏的字段
+func (r *Redactor) walk(v any, path []string) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			childPath := append(path[:len(path):len(path)], k)
+			if r.isField(k) || r.isPath(childPath) {
+				val[k] = redactedValue
+				continue
+			}
+			val[k] = r.walk(child, childPath)
+		}
+	case []any:
+		for i, child := range val {
+			childPath := append(path[:len(path):len(path)], fmt.Sprint(i))
+			if r.isPath(childPath) {
+				val[i] = redactedValue
+				continue
+			}
+			val[i] = r.walk(child, childPath)
+		}
+	}
+
+	return v
+}
+
+// isField 判断字段名是否需要脱敏
+func (r *Redactor) isField(name string) bool {
+	for _, field := range r.Fields {
+		if strings.EqualFold(field, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPath 判断 json 路径是否需要脱敏
+func (r *Redactor) isPath(path []string) bool {
+	for _, p := range r.Paths {
+		segments := strings.Split(p, ".")
+		if len(segments) != len(path) {
+			continue
+		}
+		matched := true
+		for i, segment := range segments {
+			if segment != "*" && segment != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// truncate 按 MaxBodySize 截断内容
+func (r *Redactor) truncate(s string) string {
+	if r.MaxBodySize <= 0 || len(s) <= r.MaxBodySize {
+		return s
+	}
+
+	return s[:r.MaxBodySize] + fmt.Sprintf("...(共%d字节)", len(s))
+}
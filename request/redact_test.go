@@ -0,0 +1,129 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryLogger 记录日志内容，用于测试
+type memoryLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *memoryLogger) Infof(ctx context.Context, format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *memoryLogger) Errorf(ctx context.Context, format string, v ...any) {
+	l.Infof(ctx, format, v...)
+}
+
+func (l *memoryLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
+
+// 测试请求头、字段、json 路径与查询参数脱敏
+func TestRedactor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"users":[{"name":"tom","phone":"13800000000"}],"token":"resp-token"}}`))
+	}))
+	defer server.Close()
+
+	logger := &memoryLogger{}
+	client := NewClient(
+		WithLogger(logger),
+		WithRedactor(Redactor{Fields: []string{"password", "token"}, Paths: []string{"data.users.*.phone"}}),
+	)
+	reqData := map[string]any{"name": "tom", "password": "secret", "profile": map[string]any{"token": "nested-token"}}
+	header := map[string]string{"Authorization": "Bearer abc", "X-Trace": "trace-id", "Content-Type": ApplicationJson}
+	if _, err := client.Post(context.Background(), server.URL+"?token=query-token", reqData, header); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	log := logger.String()
+	for _, secret := range []string{"Bearer abc", "secret", "nested-token", "13800000000", "resp-token", "query-token"} {
+		if strings.Contains(log, secret) {
+			t.Errorf("日志中包含敏感内容 %q：%s", secret, log)
+		}
+	}
+	for _, keep := range []string{"trace-id", `"name":"tom"`} {
+		if !strings.Contains(log, keep) {
+			t.Errorf("日志中缺少内容 %q：%s", keep, log)
+		}
+	}
+}
+
+// 测试日志级别与内容截断
+func TestLogLevel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer server.Close()
+
+	logger := &memoryLogger{}
+	_, _ = NewClient(WithLogger(logger), WithLogLevel(LogLevelOff)).Get(context.Background(), server.URL, nil, nil)
+	if logger.String() != "" {
+		t.Errorf("LogLevelOff 不应记录日志：%s", logger.String())
+	}
+
+	_, _ = NewClient(WithLogger(logger), WithLogLevel(LogLevelSummary)).Get(context.Background(), server.URL, nil, nil)
+	if log := logger.String(); !strings.Contains(log, "状态码：200") || strings.Contains(log, "aaaa") {
+		t.Errorf("LogLevelSummary 日志不符合预期：%s", log)
+	}
+
+	logger = &memoryLogger{}
+	_, _ = NewClient(WithLogger(logger), WithRedactor(Redactor{MaxBodySize: 10})).Get(context.Background(), server.URL, nil, nil)
+	if log := logger.String(); !strings.Contains(log, "aaaaaaaaaa...(共100字节)") {
+		t.Errorf("返回内容未截断：%s", log)
+	}
+}
+
+// 测试请求失败时错误日志不包含未脱敏的返回内容与查询参数
+func TestRedactor_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json" {
+			_, _ = w.Write([]byte(`{"password":"hunter2"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"password":"hunter2"}`))
+	}))
+	defer server.Close()
+
+	redactor := WithRedactor(Redactor{Fields: []string{"password", "token"}})
+	for _, level := range []LogLevel{LogLevelFull, LogLevelSummary} {
+		logger := &memoryLogger{}
+		client := NewClient(WithLogger(logger), redactor, WithLogLevel(level))
+		_, err := client.Get(context.Background(), server.URL+"?token=query-token", nil, nil)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || !strings.Contains(string(statusErr.Body), "hunter2") {
+			t.Errorf("StatusError.Body 应保留返回内容：%v", err)
+		}
+		_, err = GetJSON[[]string](context.Background(), client, server.URL+"/json", nil, nil)
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) || !strings.Contains(decodeErr.Snippet, "hunter2") {
+			t.Errorf("DecodeError.Snippet 应保留返回内容：%v", err)
+		}
+
+		log := logger.String()
+		if !strings.Contains(log, "返回状态码 400") {
+			t.Errorf("日志中没有错误信息：%s", log)
+		}
+		for _, secret := range []string{"hunter2", "query-token"} {
+			if strings.Contains(log, secret) {
+				t.Errorf("日志级别 %d 的日志中包含 %s：%s", level, secret, log)
+			}
+		}
+	}
+}
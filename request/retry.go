@@ -0,0 +1,175 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	// MaxAttempts 最大请求次数（包含首次请求），小于等于 1 表示不重试
+	MaxAttempts int
+	// BaseDelay 首次重试前的等待时间
+	BaseDelay time.Duration
+	// MaxDelay 单次等待时间上限，0 表示不限制
+	MaxDelay time.Duration
+	// Multiplier 每次重试等待时间的增长倍数，小于 1 时按 2 处理
+	Multiplier float64
+	// Jitter 随机抖动比例，取值 0~1，等待时间会在 [delay*(1-Jitter), delay] 之间随机
+	Jitter float64
+	// RetryableStatus 需要重试的http状态码
+	RetryableStatus []int
+	// RetryableError 判断请求错误是否需要重试，为空时使用默认规则（网络错误、连接被重置等）
+	RetryableError func(err error) bool
+	// RetryNonIdempotent 是否重试 POST、PATCH 等非幂等请求
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy 默认重试策略：最多请求 3 次，等待时间从 100ms 开始指数增长
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Multiplier:  2,
+		Jitter:      0.2,
+		RetryableStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// WithRetry 设置重试策略
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = &policy
+	}
+}
+
+// maxAttempts 计算该请求方法允许的最大请求次数
+func (p *RetryPolicy) maxAttempts(method string) int {
+	if p == nil || p.MaxAttempts <= 1 {
+		return 1
+	}
+	if !p.RetryNonIdempotent && !isIdempotent(method) {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+// shouldRetry 根据请求错误或返回状态码判断是否需要重试
+func (p *RetryPolicy) shouldRetry(statusCode int, err error) bool {
+	if err != nil {
+		if p.RetryableError != nil {
+			return p.RetryableError(err)
+		}
+		return isRetryableError(err)
+	}
+	for _, code := range p.RetryableStatus {
+		if statusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// delay 计算第 attempt 次请求失败后的等待时间，429/503 优先使用 Retry-After
+func (p *RetryPolicy) delay(attempt int, statusCode int, header http.Header) time.Duration {
+	if statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable {
+		if d, ok := parseRetryAfter(header.Get("Retry-After")); ok {
+			if p.MaxDelay > 0 && d > p.MaxDelay {
+				return p.MaxDelay
+			}
+			return d
+		}
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	d := float64(p.BaseDelay) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d -= d * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+
+	return time.Duration(d)
+}
+
+// parseRetryAfter 解析 Retry-After，支持秒数与http日期两种格式
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+// isIdempotent 判断请求方法是否幂等
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRetryableError 默认的错误重试规则
+func isRetryableError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	// url.Error 本身实现了 net.Error，需要取出内部错误再判断
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+
+	return errors.As(err, &netErr)
+}
+
+// sleep 等待指定时间，ctx 结束时提前返回
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
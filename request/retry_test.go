@@ -0,0 +1,123 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingServer 前 failures 次请求返回 status，之后返回 200
+func failingServer(failures int32, status int, header map[string]string) (*httptest.Server, *int32) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= failures {
+			for k, v := range header {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	return server, &count
+}
+
+// 测试失败 N 次后重试成功
+func TestRetry_SucceedAfterFailures(t *testing.T) {
+	server, count := failingServer(2, http.StatusBadGateway, nil)
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := NewClient(WithRetry(policy))
+	resp, err := client.Do(context.Background(), http.MethodGet, server.URL, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if string(resp.Body) != "ok" || resp.Attempts != 3 || atomic.LoadInt32(count) != 3 {
+		t.Errorf("Do() body = %q, attempts = %d, server count = %d; want ok, 3, 3", resp.Body, resp.Attempts, *count)
+	}
+}
+
+// 测试超过最大次数后停止重试
+func TestRetry_Exhausted(t *testing.T) {
+	server, count := failingServer(5, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := NewClient(WithRetry(policy))
+	resp, _ := client.Do(context.Background(), http.MethodGet, server.URL, nil, nil)
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable || atomic.LoadInt32(count) != 3 {
+		t.Errorf("Do() 期望请求 3 次后返回 503，实际请求 %d 次", *count)
+	}
+}
+
+// 测试 post 请求默认不重试，开启 RetryNonIdempotent 后重试
+func TestRetry_NonIdempotent(t *testing.T) {
+	server, count := failingServer(1, http.StatusBadGateway, nil)
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := NewClient(WithRetry(policy))
+	resp, _ := client.Do(context.Background(), http.MethodPost, server.URL, map[string]any{"a": 1}, nil)
+	if resp.StatusCode != http.StatusBadGateway || atomic.LoadInt32(count) != 1 {
+		t.Errorf("post 请求不应重试，实际请求 %d 次", *count)
+	}
+
+	atomic.StoreInt32(count, 0)
+	policy.RetryNonIdempotent = true
+	client = NewClient(WithRetry(policy))
+	resp, err := client.Do(context.Background(), http.MethodPost, server.URL, map[string]any{"a": 1}, nil)
+	if err != nil || string(resp.Body) != "ok" || atomic.LoadInt32(count) != 2 {
+		t.Errorf("开启 RetryNonIdempotent 后 post 请求应重试成功，实际请求 %d 次，error = %v", *count, err)
+	}
+}
+
+// 测试 429 时使用 Retry-After 作为等待时间
+func TestRetry_RetryAfter(t *testing.T) {
+	server, _ := failingServer(1, http.StatusTooManyRequests, map[string]string{"Retry-After": "1"})
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := NewClient(WithRetry(policy))
+	start := time.Now()
+	if _, err := client.Do(context.Background(), http.MethodGet, server.URL, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Retry-After 未生效，实际等待 %s", elapsed)
+	}
+}
+
+// 测试网络错误重试
+func TestRetry_NetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	reqUrl := server.URL
+	server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := NewClient(WithRetry(policy))
+	resp, err := client.Do(context.Background(), http.MethodGet, reqUrl, nil, nil)
+	if err == nil || resp != nil {
+		t.Errorf("Do() 期望返回连接错误，实际 error = %v", err)
+	}
+}
+
+// 测试退避时间计算
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond, Multiplier: 2}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i, w := range want {
+		if d := policy.delay(i+1, 0, nil); d != w {
+			t.Errorf("delay(%d) = %s; want %s", i+1, d, w)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package request
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signer 请求签名，在请求体编码完成后对每一次实际发出的请求签名，签名覆盖实际发送的请求体
+type Signer interface {
+	// Sign 为请求签名，body 为实际发送的请求体，没有请求体时为空
+	Sign(req *http.Request, body []byte) error
+}
+
+// SignerFunc 自定义签名函数，用于实现合作方的私有签名规则
+type SignerFunc func(req *http.Request, body []byte) error
+
+// Sign 实现 Signer
+func (f SignerFunc) Sign(req *http.Request, body []byte) error {
+	return f(req, body)
+}
+
+// WithSigner 设置请求签名，签名在认证之后执行，重试时重新签名
+// 流式请求体（例如 multipart 上传）需要读入内存后才能签名
+func WithSigner(signer Signer) Option {
+	return func(c *Client) {
+		c.signer = signer
+	}
+}
+
+// signMiddleware 签名中间件
+func signMiddleware(signer Signer) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			body, err := readBody(req)
+			if err != nil {
+				return nil, err
+			}
+			if err = signer.Sign(req, body); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	}
+}
+
+// readBody 读取请求体并重置，使请求体可以再次读取
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+	req.ContentLength = int64(len(body))
+
+	return body, nil
+}
+
+// HMACSigner 通用 HMAC-SHA256 签名，待签名字符串由以下各行组成：
+// 请求方法、路径、按参数名排序的查询参数、时间戳、随机串、请求体的 sha256 摘要
+type HMACSigner struct {
+	// AccessKey 访问密钥 id，为空时不设置 KeyHeader
+	AccessKey string
+	// SecretKey 签名密钥
+	SecretKey string
+	// KeyHeader 访问密钥 id 的请求头，默认 X-Access-Key
+	KeyHeader string
+	// TimestampHeader 时间戳（秒）的请求头，默认 X-Timestamp
+	TimestampHeader string
+	// NonceHeader 随机串的请求头，默认 X-Nonce
+	NonceHeader string
+	// SignatureHeader 签名的请求头，默认 X-Signature，签名为十六进制小写
+	SignatureHeader string
+	// Canonical 自定义待签名字符串，为空时使用默认规则
+	Canonical func(req *http.Request, body []byte, timestamp, nonce string) string
+
+	now func() time.Time
+}
+
+// Sign 实现 Signer
+func (s *HMACSigner) Sign(req *http.Request, body []byte) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(s.time().Unix(), 10)
+	nonceStr := hex.EncodeToString(nonce)
+
+	canonical := s.Canonical
+	if canonical == nil {
+		canonical = CanonicalString
+	}
+	mac := hmac.New(sha256.New, []byte(s.SecretKey))
+	mac.Write([]byte(canonical(req, body, timestamp, nonceStr)))
+
+	if s.AccessKey != "" {
+		req.Header.Set(headerOr(s.KeyHeader, "X-Access-Key"), s.AccessKey)
+	}
+	req.Header.Set(headerOr(s.TimestampHeader, "X-Timestamp"), timestamp)
+	req.Header.Set(headerOr(s.NonceHeader, "X-Nonce"), nonceStr)
+	req.Header.Set(headerOr(s.SignatureHeader, "X-Signature"), hex.EncodeToString(mac.Sum(nil)))
+
+	return nil
+}
+
+// time 当前时间
+func (s *HMACSigner) time() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+
+	return time.Now()
+}
+
+// CanonicalString HMACSigner 的默认待签名字符串，查询参数按参数名与参数值排序后编码
+func CanonicalString(req *http.Request, body []byte, timestamp, nonce string) string {
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		path,
+		sortedQuery(req.URL.Query(), url.QueryEscape),
+		timestamp,
+		nonce,
+		sha256Hex(body),
+	}, "\n")
+}
+
+// headerOr 请求头为空时使用默认值
+func headerOr(name, def string) string {
+	if name == "" {
+		return def
+	}
+
+	return name
+}
+
+// sortedQuery 按编码后的参数名排序、参数名相同时按参数值排序后拼接查询参数
+// 不能直接对拼接后的 k=v 排序，= 会排在 - . 与数字之后，a 与 a1 这类前缀相同的参数名顺序会出错
+func sortedQuery(query url.Values, escape func(string) string) string {
+	pairs := make([][2]string, 0, len(query))
+	for k, vs := range query {
+		for _, v := range vs {
+			pairs = append(pairs, [2]string{escape(k), escape(v)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	parts := make([]string, len(pairs))
+	for i, pair := range pairs {
+		parts[i] = pair[0] + "=" + pair[1]
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// sha256Hex sha256 摘要的十六进制小写
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 计算 HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// awsIgnoredHeaders AWS 签名中不参与签名的请求头，可能被代理修改
+var awsIgnoredHeaders = map[string]bool{
+	"Authorization":   true,
+	"User-Agent":      true,
+	"X-Amzn-Trace-Id": true,
+	"Expect":          true,
+	"Connection":      true,
+}
+
+// AWSSigner AWS Signature Version 4 签名，可用于 S3 及 MinIO 等兼容 S3 的服务
+type AWSSigner struct {
+	// AccessKey 访问密钥 id
+	AccessKey string
+	// SecretKey 访问密钥
+	SecretKey string
+	// SessionToken 临时凭证的会话令牌，可以为空
+	SessionToken string
+	// Region 区域，MinIO 默认为 us-east-1
+	Region string
+	// Service 服务名，例如 s3
+	Service string
+	// UnsignedPayload 不对请求体签名，仅 s3 支持，用于大文件上传
+	UnsignedPayload bool
+
+	now func() time.Time
+}
+
+// Sign 实现 Signer
+func (s *AWSSigner) Sign(req *http.Request, body []byte) error {
+	now := time.Now().UTC()
+	if s.now != nil {
+		now = s.now().UTC()
+	}
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	if s.UnsignedPayload {
+		payloadHash = "UNSIGNED-PAYLOAD"
+	}
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, vs := range req.Header {
+		if awsIgnoredHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		values := make([]string, len(vs))
+		for i, v := range vs {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[strings.ToLower(k)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	path = awsEscape(path, false)
+	// 除 s3 外的服务需要对路径二次编码
+	if s.Service != "s3" {
+		path = awsEscape(path, false)
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		sortedQuery(req.URL.Query(), func(v string) string { return awsEscape(v, true) }),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+
+	return nil
+}
+
+// awsEscape 按 AWS 规则编码，只保留字母、数字与 -_.~，encodeSlash 为 false 时保留 /
+func awsEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || ch == '/' && !encodeSlash {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{ch})))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,125 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"github.com/zeromicro/go-zero/core/syncx"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DedupStats 请求合并统计
+type DedupStats struct {
+	// Requests 参与合并的 get 请求数
+	Requests int64
+	// Collapsed 被合并、没有实际发出的请求数
+	Collapsed int64
+}
+
+// WithSingleflight 合并同时发出的相同 get 请求，只有一个请求实际发出，其余请求共享返回结果或错误
+// 请求方法、地址与查询参数相同即视为相同请求，headers 为额外参与比较的请求头，例如 Authorization
+func WithSingleflight(headers ...string) Option {
+	return func(c *Client) {
+		c.dedup = &dedupGroup{flight: syncx.NewSingleFlight(), headers: headers}
+	}
+}
+
+// DedupStats 获取请求合并统计，未开启时返回零值
+func (c *Client) DedupStats() DedupStats {
+	if c.dedup == nil {
+		return DedupStats{}
+	}
+
+	return DedupStats{
+		Requests:  atomic.LoadInt64(&c.dedup.requests),
+		Collapsed: atomic.LoadInt64(&c.dedup.collapsed),
+	}
+}
+
+// dedupGroup 请求合并
+type dedupGroup struct {
+	flight    syncx.SingleFlight
+	headers   []string
+	requests  int64
+	collapsed int64
+}
+
+// dedupResult 共享的请求结果
+type dedupResult struct {
+	resp *Response
+	err  error
+}
+
+// do 合并相同请求，每个调用方拿到独立的返回结果副本，调用方的 ctx 结束时直接返回，不影响其他调用方
+func (g *dedupGroup) do(ctx context.Context, req *http.Request, fn func() (*Response, error)) (*Response, error) {
+	atomic.AddInt64(&g.requests, 1)
+	done := make(chan dedupResult, 1)
+	go func() {
+		val, fresh, _ := g.flight.DoEx(g.key(req), func() (any, error) {
+			resp, err := fn()
+			return dedupResult{resp: resp, err: err}, nil
+		})
+		result := val.(dedupResult)
+		if !fresh {
+			atomic.AddInt64(&g.collapsed, 1)
+		}
+		// 首个调用方同样拿到副本，避免与其他调用方复制时并发读写
+		result.resp = result.resp.clone()
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		return result.resp, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// key 请求合并键，由请求方法、地址与指定请求头组成
+func (g *dedupGroup) key(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte(' ')
+	b.WriteString(req.URL.String())
+	for _, name := range g.headers {
+		b.WriteByte('\n')
+		b.WriteString(http.CanonicalHeaderKey(name))
+		b.WriteByte(':')
+		b.WriteString(req.Header.Get(name))
+	}
+
+	return b.String()
+}
+
+// clone 复制返回结果，避免调用方修改共享的返回头与内容
+func (r *Response) clone() *Response {
+	if r == nil {
+		return nil
+	}
+	resp := *r
+	resp.Header = r.Header.Clone()
+	resp.Body = bytes.Clone(r.Body)
+
+	return &resp
+}
+
+// sendDedup 开启请求合并时合并相同的 get 请求，实际发出的请求不随首个调用方的 ctx 取消，由客户端超时时间限制
+func (c *Client) sendDedup(ctx context.Context, req *http.Request, timeout time.Duration) (*Response, error) {
+	send := func(ctx context.Context, req *http.Request) (*Response, error) {
+		if c.cache != nil {
+			return c.cache.do(ctx, c, req, timeout)
+		}
+		return c.send(ctx, req, timeout)
+	}
+	if c.dedup == nil {
+		return send(ctx, req)
+	}
+
+	return c.dedup.do(ctx, req, func() (*Response, error) {
+		shared := context.WithoutCancel(ctx)
+		return send(shared, req.WithContext(shared))
+	})
+}
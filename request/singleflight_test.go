@@ -0,0 +1,123 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试合并同时发出的相同 get 请求
+func TestWithSingleflight(t *testing.T) {
+	var count int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		<-release
+		_, _ = w.Write([]byte(r.URL.Query().Get("id") + r.Header.Get("X-Tenant")))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithSingleflight("X-Tenant"))
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, err := client.Get(context.Background(), server.URL, map[string]any{"id": 1}, map[string]string{"X-Tenant": "a"})
+			if err != nil {
+				t.Errorf("Get() error = %v", err)
+			}
+			bodies[i] = string(body)
+			// 每个调用方拿到独立的副本，修改不影响其他调用方
+			if len(body) > 0 {
+				body[0] = 'x'
+			}
+		}(i)
+	}
+	// 等待所有请求进入合并
+	deadline := time.Now().Add(time.Second)
+	for client.DedupStats().Requests < 10 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	for i, body := range bodies {
+		if body != "1a" {
+			t.Errorf("第%d个请求 body = %q; want 1a", i+1, body)
+		}
+	}
+	if count != 1 {
+		t.Errorf("实际请求 %d 次; want 1", count)
+	}
+	if stats := client.DedupStats(); stats.Requests != 10 || stats.Collapsed != 9 {
+		t.Errorf("DedupStats() = %+v", stats)
+	}
+}
+
+// 测试参与比较的请求头不同时不合并
+func TestDedupGroup_Key(t *testing.T) {
+	g := &dedupGroup{headers: []string{"authorization"}}
+	a, _ := http.NewRequest(http.MethodGet, "http://example.com/a?id=1", nil)
+	b := a.Clone(context.Background())
+	a.Header.Set("Authorization", "token-a")
+	b.Header.Set("Authorization", "token-b")
+	if g.key(a) == g.key(b) {
+		t.Errorf("不同 Authorization 的请求不应合并")
+	}
+	b.Header.Set("Authorization", "token-a")
+	b.Header.Set("X-Request-Id", "1")
+	if g.key(a) != g.key(b) {
+		t.Errorf("未指定的请求头不应影响合并")
+	}
+}
+
+// 测试首个调用方取消后其他合并的调用方仍然拿到返回结果
+func TestWithSingleflight_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithSingleflight(), WithLogger(&memoryLogger{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.Get(ctx, server.URL, nil, nil)
+		first <- err
+	}()
+	for client.DedupStats().Requests < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan string, 1)
+	go func() {
+		body, err := client.Get(context.Background(), server.URL, nil, nil)
+		if err != nil {
+			t.Errorf("第2个请求 error = %v", err)
+		}
+		second <- string(body)
+	}()
+	for client.DedupStats().Requests < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("第1个请求 error = %v; want context.Canceled", err)
+	}
+	close(release)
+	if body := <-second; body != "ok" {
+		t.Errorf("第2个请求 body = %q; want ok", body)
+	}
+	if stats := client.DedupStats(); stats.Collapsed != 1 {
+		t.Errorf("DedupStats() = %+v", stats)
+	}
+}
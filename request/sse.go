@@ -0,0 +1,314 @@
+package request
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSSERetry 默认重连等待时间，服务端可以通过 retry 字段修改
+const defaultSSERetry = 3 * time.Second
+
+// defaultMaxEventSize 默认单行最大字节数
+const defaultMaxEventSize = 1 << 20
+
+// Event 服务端推送事件
+type Event struct {
+	// ID 事件 id，断线重连时通过 Last-Event-ID 请求头发送最后收到的 id
+	ID string
+	// Event 事件类型，默认为 message
+	Event string
+	// Data 事件内容，多行 data 以换行符拼接
+	Data string
+	// Retry 服务端指定的重连等待时间，未指定时为 0
+	Retry time.Duration
+}
+
+// SSEOptions 订阅配置
+type SSEOptions struct {
+	// Method 请求方法，默认 GET
+	Method string
+	// ReqData get 请求时作为查询参数，其余请求编码为 json 请求体，例如大模型网关的对话参数
+	ReqData any
+	// Header 请求头
+	Header map[string]string
+	// LastEventID 首次连接时的 Last-Event-ID
+	LastEventID string
+	// RetryDelay 重连等待时间，默认 3 秒，服务端返回 retry 字段后使用服务端的值
+	RetryDelay time.Duration
+	// MaxReconnects 连续重连的最大次数，收到事件后重新计数，0 表示不限制
+	MaxReconnects int
+	// DisableReconnect 连接断开后不重连
+	DisableReconnect bool
+	// MaxEventSize 单行最大字节数，默认 1MB，超过时返回 bufio.ErrTooLong 并停止订阅
+	MaxEventSize int
+}
+
+// Subscribe 订阅 text/event-stream，每收到一个事件调用一次 handler，handler 返回错误时停止订阅并返回该错误
+// 连接断开后携带 Last-Event-ID 自动重连，ctx 结束时返回 ctx.Err()，服务端返回 204 或不重连时连接正常关闭返回 nil
+// 订阅不受客户端默认超时时间限制，状态码不是 2xx 或 Content-Type 不是 text/event-stream 时不会重连
+func (c *Client) Subscribe(ctx context.Context, reqUrl string, opts *SSEOptions, handler func(Event) error) error {
+	if opts == nil {
+		opts = &SSEOptions{}
+	}
+	method := strings.ToUpper(opts.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	ctx, span := c.startSpan(ctx, method, reqUrl)
+	var (
+		statusCode int
+		err        error
+	)
+	defer func() {
+		endSpan(span, statusCode, 0, 1, err)
+	}()
+
+	stream := &sseStream{lastEventID: opts.LastEventID, retry: opts.RetryDelay}
+	if stream.retry <= 0 {
+		stream.retry = defaultSSERetry
+	}
+	var failures int
+	for {
+		var received bool
+		statusCode, received, err = c.subscribeOnce(ctx, method, reqUrl, opts, stream, handler)
+		if received {
+			failures = 0
+		}
+		var fatal *sseFatalError
+		switch {
+		case ctx.Err() != nil:
+			err = ctx.Err()
+			return err
+		case errors.As(err, &fatal):
+			err = fatal.err
+			return err
+		case statusCode == http.StatusNoContent:
+			err = nil
+			return nil
+		case opts.DisableReconnect:
+			return err
+		}
+
+		failures++
+		if opts.MaxReconnects > 0 && failures > opts.MaxReconnects {
+			if err == nil {
+				err = io.EOF
+			}
+			err = fmt.Errorf("超过最大重连次数 %d：%w", opts.MaxReconnects, err)
+			return err
+		}
+		if err != nil {
+			c.logger.Errorf(ctx, "事件流连接断开，%s后重连: %s", stream.retry, err)
+		}
+		span.AddEvent("sse.reconnect")
+		if err = sleep(ctx, stream.retry); err != nil {
+			return err
+		}
+	}
+}
+
+// Events 订阅 text/event-stream，通过 channel 返回事件，订阅结束后关闭事件 channel 并在错误 channel 中返回结束原因
+func (c *Client) Events(ctx context.Context, reqUrl string, opts *SSEOptions) (<-chan Event, <-chan error) {
+	events := make(chan Event)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(events)
+		errs <- c.Subscribe(ctx, reqUrl, opts, func(event Event) error {
+			select {
+			case events <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(errs)
+	}()
+
+	return events, errs
+}
+
+// sseFatalError 不需要重连的错误
+type sseFatalError struct {
+	err error
+}
+
+func (e *sseFatalError) Error() string {
+	return e.err.Error()
+}
+
+// sseStream 跨连接保持的订阅状态
+type sseStream struct {
+	lastEventID string
+	retry       time.Duration
+}
+
+// subscribeOnce 建立一次连接并读取事件，received 表示是否收到过事件
+func (c *Client) subscribeOnce(ctx context.Context, method, reqUrl string, opts *SSEOptions, stream *sseStream, handler func(Event) error) (int, bool, error) {
+	req, err := c.newSSERequest(ctx, method, reqUrl, opts, stream.lastEventID)
+	if err != nil {
+		return 0, false, &sseFatalError{err: err}
+	}
+	resp, err := c.handler(req)
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, false, nil
+	}
+	if !c.accept(resp.StatusCode) {
+		return resp.StatusCode, false, &sseFatalError{err: c.downloadStatusError(resp)}
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		return resp.StatusCode, false, &sseFatalError{err: fmt.Errorf("%w：%q，预期：text/event-stream", ErrUnexpectedContentType, resp.Header.Get("Content-Type"))}
+	}
+
+	var received bool
+	err = parseEvents(resp.Body, opts.MaxEventSize, stream, func(event Event) error {
+		received = true
+		if err := handler(event); err != nil {
+			return &sseFatalError{err: err}
+		}
+		return nil
+	})
+	// 超长的行重连后仍然超长，不再重连
+	if errors.Is(err, bufio.ErrTooLong) {
+		err = &sseFatalError{err: fmt.Errorf("事件单行超过最大字节数：%w", err)}
+	}
+
+	return resp.StatusCode, received, err
+}
+
+// newSSERequest 初始化订阅请求
+func (c *Client) newSSERequest(ctx context.Context, method, reqUrl string, opts *SSEOptions, lastEventID string) (*http.Request, error) {
+	header := c.mergeHeader(opts.Header)
+	header["Accept"] = "text/event-stream"
+	header["Cache-Control"] = "no-cache"
+	if lastEventID != "" {
+		header["Last-Event-ID"] = lastEventID
+	}
+
+	var body io.Reader
+	if hasBody(method) && opts.ReqData != nil {
+		data, err := json.Marshal(opts.ReqData)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+		if _, ok := header["Content-Type"]; !ok {
+			header["Content-Type"] = ApplicationJson
+		}
+	}
+	req, err := c.newRequest(ctx, method, c.resolveURL(reqUrl), body, header)
+	if err != nil {
+		return nil, err
+	}
+	if !hasBody(method) {
+		query := req.URL.Query()
+		if err = encodeValues(query, opts.ReqData, "query"); err != nil {
+			return nil, err
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+
+	return req, nil
+}
+
+// parseEvents 按 html 规范解析事件流，直到读取结束或 dispatch 返回错误
+func parseEvents(r io.Reader, maxSize int, stream *sseStream, dispatch func(Event) error) error {
+	if maxSize <= 0 {
+		maxSize = defaultMaxEventSize
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(4096, maxSize)), maxSize)
+	scanner.Split(scanLines)
+
+	var (
+		event Event
+		data  strings.Builder
+		first = true
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+
+		// 空行表示一个事件结束
+		if line == "" {
+			if data.Len() > 0 {
+				event.ID = stream.lastEventID
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				if event.Event == "" {
+					event.Event = "message"
+				}
+				if err := dispatch(event); err != nil {
+					return err
+				}
+			}
+			event, data = Event{}, strings.Builder{}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				stream.lastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+				event.Retry = time.Duration(ms) * time.Millisecond
+				stream.retry = event.Retry
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// scanLines 按 \r\n、\n 或 \r 分行
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// \r 位于末尾时需要更多数据判断是否为 \r\n
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
@@ -0,0 +1,165 @@
+package request
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试按规范解析事件流
+func TestParseEvents(t *testing.T) {
+	stream := "\ufeff: 注释\r\n" +
+		"event: add\r\ndata: 第一行\r\ndata:第二行\r\nid: 1\r\n\r\n" +
+		"data\n\n" +
+		"id: 2\nretry: 1500\ndata: {\"a\":1}\n\n" +
+		"event: ping\n\n" +
+		"id: a\x00b\rdata: 末尾\r\r" +
+		"data: 未结束的事件"
+	state := &sseStream{}
+	var events []Event
+	err := parseEvents(strings.NewReader(stream), 0, state, func(event Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parseEvents() error = %v", err)
+	}
+
+	want := []Event{
+		{ID: "1", Event: "add", Data: "第一行\n第二行"},
+		{ID: "1", Event: "message", Data: ""},
+		{ID: "2", Event: "message", Data: `{"a":1}`, Retry: 1500 * time.Millisecond},
+		{ID: "2", Event: "message", Data: "末尾"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("parseEvents() = %+v; want %+v", events, want)
+	}
+	if state.lastEventID != "2" || state.retry != 1500*time.Millisecond {
+		t.Errorf("parseEvents() lastEventID = %q, retry = %s; want 2, 1.5s", state.lastEventID, state.retry)
+	}
+}
+
+// 测试断线后携带 Last-Event-ID 自动重连，服务端返回 204 时结束
+func TestClient_Subscribe(t *testing.T) {
+	var connects int32
+	var lastEventIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&connects, 1)
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("Accept = %q; want text/event-stream", r.Header.Get("Accept"))
+		}
+		if n > 2 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		_, _ = fmt.Fprintf(w, "retry: 10\n\nid: %d\ndata: 第%d条\n\n", n, n)
+	}))
+	defer server.Close()
+
+	var data []string
+	err := NewClient(WithLogger(&memoryLogger{})).Subscribe(context.Background(), server.URL, &SSEOptions{LastEventID: "0"}, func(event Event) error {
+		data = append(data, event.Data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if !reflect.DeepEqual(data, []string{"第1条", "第2条"}) {
+		t.Errorf("Subscribe() data = %v", data)
+	}
+	if !reflect.DeepEqual(lastEventIDs, []string{"0", "1", "2"}) {
+		t.Errorf("Last-Event-ID = %v; want [0 1 2]", lastEventIDs)
+	}
+}
+
+// 测试 handler 返回错误、状态码错误与超过最大重连次数时停止订阅
+func TestClient_SubscribeStop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/html":
+			_, _ = w.Write([]byte("<html></html>"))
+		case "/empty":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(": 心跳\n\n"))
+		default:
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: ok\n\n"))
+		}
+	}))
+	defer server.Close()
+	client := NewClient(WithLogger(&memoryLogger{}))
+
+	errStop := errors.New("stop")
+	err := client.Subscribe(context.Background(), server.URL, nil, func(Event) error { return errStop })
+	if !errors.Is(err, errStop) {
+		t.Errorf("Subscribe() error = %v; want stop", err)
+	}
+
+	err = client.Subscribe(context.Background(), server.URL+"/error", nil, func(Event) error { return nil })
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Subscribe() error = %v; want StatusError 500", err)
+	}
+
+	err = client.Subscribe(context.Background(), server.URL+"/html", nil, func(Event) error { return nil })
+	if !errors.Is(err, ErrUnexpectedContentType) {
+		t.Errorf("Subscribe() error = %v; want ErrUnexpectedContentType", err)
+	}
+
+	err = client.Subscribe(context.Background(), server.URL, &SSEOptions{MaxEventSize: 4, RetryDelay: time.Millisecond, MaxReconnects: 2}, func(Event) error { return nil })
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("Subscribe() error = %v; want bufio.ErrTooLong", err)
+	}
+
+	opts := &SSEOptions{RetryDelay: time.Millisecond, MaxReconnects: 2}
+	err = client.Subscribe(context.Background(), server.URL+"/empty", opts, func(Event) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "超过最大重连次数") {
+		t.Errorf("Subscribe() error = %v; want 超过最大重连次数", err)
+	}
+
+	// 每次连接都收到事件时重新计数，直到 ctx 结束
+	var received int
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = client.Subscribe(ctx, server.URL, opts, func(Event) error {
+		received++
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || received < 3 {
+		t.Errorf("Subscribe() error = %v, received = %d; want DeadlineExceeded", err, received)
+	}
+}
+
+// 测试通过 channel 接收事件
+func TestClient_Events(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != ApplicationJson {
+			t.Errorf("request = %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: delta\ndata: a\n\nevent: delta\ndata: b\n\n"))
+	}))
+	defer server.Close()
+
+	opts := &SSEOptions{Method: http.MethodPost, ReqData: map[string]any{"stream": true}, DisableReconnect: true}
+	events, errs := NewClient(WithLogger(&memoryLogger{})).Events(context.Background(), server.URL, opts)
+	var data string
+	for event := range events {
+		data += event.Data
+	}
+	if err := <-errs; err != nil || data != "ab" {
+		t.Errorf("Events() data = %q, error = %v", data, err)
+	}
+}
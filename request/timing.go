@@ -0,0 +1,143 @@
+package request
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing 单次请求各阶段耗时，复用连接时 DNS、Connect、TLS 为 0
+type Timing struct {
+	// DNS 域名解析耗时
+	DNS time.Duration
+	// Connect 建立 tcp 连接耗时
+	Connect time.Duration
+	// TLS TLS 握手耗时
+	TLS time.Duration
+	// Wait 请求发送完成到收到返回首字节的耗时，主要为服务端处理时间
+	Wait time.Duration
+	// TTFB 开始请求到收到返回首字节的耗时
+	TTFB time.Duration
+	// BodyRead 读取返回内容的耗时
+	BodyRead time.Duration
+	// Total 单次请求总耗时
+	Total time.Duration
+	// Reused 是否复用了连接池中的连接
+	Reused bool
+	// RemoteAddr 服务端地址
+	RemoteAddr string
+}
+
+// String 输出各阶段耗时
+func (t *Timing) String() string {
+	return fmt.Sprintf("dns=%s connect=%s tls=%s wait=%s ttfb=%s body=%s total=%s reused=%t remote=%s",
+		t.DNS, t.Connect, t.TLS, t.Wait, t.TTFB, t.BodyRead, t.Total, t.Reused, t.RemoteAddr)
+}
+
+// WithHTTPTrace 记录每次请求的 DNS、建连、TLS、首字节与读取返回内容的耗时，
+// 结果写入 Response.Timing、请求日志与 span 事件，用于区分网络耗时与服务端耗时
+func WithHTTPTrace() Option {
+	return func(c *Client) {
+		c.httpTrace = true
+	}
+}
+
+// phaseTimer 通过 httptrace 记录各阶段时间点，回调可能在其他 goroutine 中执行
+type phaseTimer struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wrote        time.Time
+	firstByte    time.Time
+	reused       bool
+	remoteAddr   string
+}
+
+// withPhaseTimer 开启 WithHTTPTrace 时为 ctx 添加 httptrace，未开启时返回 nil
+func (c *Client) withPhaseTimer(ctx context.Context) (context.Context, *phaseTimer) {
+	if !c.httpTrace {
+		return ctx, nil
+	}
+	p := &phaseTimer{start: time.Now()}
+	set := func(t *time.Time) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		// 多地址尝试连接时只记录第一次
+		if t.IsZero() {
+			*t = time.Now()
+		}
+	}
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { set(&p.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { set(&p.dnsDone) },
+		ConnectStart:      func(string, string) { set(&p.connectStart) },
+		ConnectDone:       func(string, string, error) { set(&p.connectDone) },
+		TLSHandshakeStart: func() { set(&p.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { set(&p.tlsDone) },
+		WroteRequest:      func(httptrace.WroteRequestInfo) { set(&p.wrote) },
+		GotFirstResponseByte: func() {
+			set(&p.firstByte)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.reused = info.Reused
+			if info.Conn != nil {
+				p.remoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+	}), p
+}
+
+// done 计算各阶段耗时并写入 span 事件，p 为 nil 时返回 nil
+func (p *phaseTimer) done(ctx context.Context) *Timing {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	timing := &Timing{
+		DNS:        between(p.dnsStart, p.dnsDone),
+		Connect:    between(p.connectStart, p.connectDone),
+		TLS:        between(p.tlsStart, p.tlsDone),
+		Wait:       between(p.wrote, p.firstByte),
+		TTFB:       between(p.start, p.firstByte),
+		BodyRead:   between(p.firstByte, now),
+		Total:      now.Sub(p.start),
+		Reused:     p.reused,
+		RemoteAddr: p.remoteAddr,
+	}
+	trace.SpanFromContext(ctx).AddEvent("http.timing", trace.WithAttributes(
+		attribute.Float64("http.timing.dns_ms", millis(timing.DNS)),
+		attribute.Float64("http.timing.connect_ms", millis(timing.Connect)),
+		attribute.Float64("http.timing.tls_ms", millis(timing.TLS)),
+		attribute.Float64("http.timing.wait_ms", millis(timing.Wait)),
+		attribute.Float64("http.timing.ttfb_ms", millis(timing.TTFB)),
+		attribute.Float64("http.timing.body_read_ms", millis(timing.BodyRead)),
+		attribute.Bool("http.timing.conn_reused", timing.Reused),
+	))
+
+	return timing
+}
+
+// between 两个时间点之间的耗时，任一时间点不存在时为 0
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+
+	return end.Sub(start)
+}
@@ -0,0 +1,67 @@
+package request
+
+import (
+	"context"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试记录各阶段耗时与连接复用
+func TestWithHTTPTrace(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	logger := &memoryLogger{}
+	client := NewClient(
+		WithHTTPTrace(),
+		WithTransport(server.Client().Transport),
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		WithLogger(logger),
+		WithLogLevel(LogLevelSummary),
+	)
+
+	resp, err := client.Do(context.Background(), http.MethodGet, server.URL, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	timing := resp.Timing
+	if timing == nil || timing.Reused || timing.Connect <= 0 || timing.TLS <= 0 || timing.RemoteAddr == "" {
+		t.Fatalf("首次请求 Timing = %v", timing)
+	}
+	if timing.Wait < 20*time.Millisecond || timing.TTFB < timing.Wait || timing.Total < timing.TTFB {
+		t.Errorf("首字节耗时错误：%v", timing)
+	}
+
+	resp, _ = client.Do(context.Background(), http.MethodGet, server.URL, nil, nil)
+	if !resp.Timing.Reused || resp.Timing.Connect != 0 || resp.Timing.TLS != 0 {
+		t.Errorf("第二次请求应复用连接：%v", resp.Timing)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || len(spans[0].Events()) != 1 || spans[0].Events()[0].Name != "http.timing" {
+		t.Errorf("span 中没有耗时事件")
+	}
+	if !strings.Contains(logger.String(), "reused=true") {
+		t.Errorf("日志中没有耗时明细：%s", logger.String())
+	}
+}
+
+// 测试未开启时不记录
+func TestWithHTTPTrace_Disabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	resp, err := NewClient().Do(context.Background(), http.MethodGet, server.URL, nil, nil)
+	if err != nil || resp.Timing != nil {
+		t.Errorf("Do() Timing = %v, error = %v", resp.Timing, err)
+	}
+}
@@ -0,0 +1,83 @@
+package request
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/url"
+	"strconv"
+)
+
+// tracerName 链路追踪的 instrumentation 名称
+const tracerName = "github.com/Songtingsen/go-utils/request"
+
+// WithTracerProvider 设置链路追踪的 TracerProvider，默认使用 otel 全局 TracerProvider
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.tracerProvider = tp
+	}
+}
+
+// tracer 获取 Tracer，未设置时在请求时读取全局 TracerProvider，以便使用 go-zero 启动后设置的全局配置
+func (c *Client) tracer() trace.Tracer {
+	tp := c.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return tp.Tracer(tracerName)
+}
+
+// urlTemplateKey ctx 中保存地址模板的键
+type urlTemplateKey struct{}
+
+// ContextWithURLTemplate 设置请求地址的低基数模板，例如 /users/{id}，作为 url.template 属性与 span 名称的一部分
+// 未设置时 span 名称只有请求方法，避免路径中的 id 等参数导致 span 名称数量无限增长
+func ContextWithURLTemplate(ctx context.Context, template string) context.Context {
+	return context.WithValue(ctx, urlTemplateKey{}, template)
+}
+
+// startSpan 创建客户端 span，按 OpenTelemetry 语义约定，有地址模板时名称为 {method} {url.template}，否则为 {method}
+func (c *Client) startSpan(ctx context.Context, method, reqUrl string) (context.Context, trace.Span) {
+	name := method
+	attrs := []attribute.KeyValue{semconv.HTTPRequestMethodKey.String(method)}
+	if template, _ := ctx.Value(urlTemplateKey{}).(string); template != "" {
+		name += " " + template
+		attrs = append(attrs, semconv.URLTemplate(template))
+	}
+	if u, err := url.Parse(c.resolveURL(reqUrl)); err == nil {
+		u.RawQuery = ""
+		attrs = append(attrs, semconv.URLFull(u.Redacted()), semconv.ServerAddress(u.Hostname()))
+		if port, err := strconv.Atoi(u.Port()); err == nil {
+			attrs = append(attrs, semconv.ServerPort(port))
+		}
+	}
+
+	return c.tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan 记录请求结果并结束 span，statusCode 为 0 表示没有收到返回
+func endSpan(span trace.Span, statusCode, bodySize, attempts int, err error) {
+	defer span.End()
+
+	if statusCode > 0 {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode), semconv.HTTPResponseBodySize(bodySize))
+	}
+	if attempts > 1 {
+		span.SetAttributes(semconv.HTTPRequestResendCount(attempts - 1))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	if statusCode >= 400 {
+		span.SetStatus(codes.Error, strconv.Itoa(statusCode))
+	}
+}
@@ -0,0 +1,86 @@
+package request
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// spanAttr 读取 span 的属性
+func spanAttr(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+// 测试每个请求都创建客户端 span，并为所有请求方法注入链路信息
+func TestClient_Trace(t *testing.T) {
+	var traceparent string
+	var count int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		count++
+		if count == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := NewClient(WithBaseURL(server.URL), WithTracerProvider(tp), WithRetry(policy))
+
+	old := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(old)
+
+	ctx := ContextWithURLTemplate(context.Background(), "/users/{id}")
+	if _, err := client.Get(ctx, "/users/123?id=1", nil, nil); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("span 数量 = %d; want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /users/{id}" || traceparent == "" {
+		t.Errorf("span name = %q, traceparent = %q", span.Name(), traceparent)
+	}
+	if spanAttr(span, "http.response.status_code").AsInt64() != 200 ||
+		spanAttr(span, "http.request.resend_count").AsInt64() != 1 ||
+		spanAttr(span, "http.response.body.size").AsInt64() != 5 ||
+		spanAttr(span, "url.template").AsString() != "/users/{id}" {
+		t.Errorf("span 属性不符合预期: %v", span.Attributes())
+	}
+
+	// 没有地址模板时 span 名称只有请求方法，不记录 url.template
+	_, _ = client.Get(context.Background(), "/users/456", nil, nil)
+	spans = recorder.Ended()
+	if span = spans[len(spans)-1]; span.Name() != "GET" || spanAttr(span, "url.template").Type() != attribute.INVALID {
+		t.Errorf("span name = %q, url.template = %v", span.Name(), spanAttr(span, "url.template"))
+	}
+
+	// 请求失败时 span 状态为 Error
+	server.Close()
+	_, _ = client.Get(context.Background(), "/users", nil, nil)
+	spans = recorder.Ended()
+	if spans[len(spans)-1].Status().Code != codes.Error {
+		t.Errorf("请求失败时 span 状态 = %v; want Error", spans[len(spans)-1].Status())
+	}
+}
@@ -0,0 +1,187 @@
+package request
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/zeromicro/go-zero/core/conf"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 测试从 yaml 加载传输层配置
+func TestTransportConf_Load(t *testing.T) {
+	var c TransportConf
+	err := conf.LoadFromYamlBytes([]byte(`
+TLS:
+  MinVersion: "1.3"
+  ServerName: api.internal
+Proxy:
+  URL: socks5://127.0.0.1:1080
+  NoProxy: [localhost, .internal]
+MaxIdleConnsPerHost: 50
+IdleConnTimeout: 30s
+`), &c)
+	if err != nil {
+		t.Fatalf("LoadFromYamlBytes() error = %v", err)
+	}
+	transport, err := NewTransport(c)
+	if err != nil {
+		t.Fatalf("NewTransport() error = %v", err)
+	}
+	if transport.MaxIdleConnsPerHost != 50 || transport.IdleConnTimeout != 30*time.Second {
+		t.Errorf("连接池配置未生效：%d %s", transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS13 || transport.TLSClientConfig.ServerName != "api.internal" {
+		t.Errorf("TLS 配置未生效")
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.internal/a", nil)
+	if proxy, _ := transport.Proxy(req); proxy != nil {
+		t.Errorf("NoProxy 中的主机不应使用代理")
+	}
+	req, _ = http.NewRequest(http.MethodGet, "https://example.com/a", nil)
+	if proxy, _ := transport.Proxy(req); proxy == nil || proxy.Scheme != "socks5" {
+		t.Errorf("Proxy() = %v; want socks5", proxy)
+	}
+
+	if _, err = NewTransport(TransportConf{TLS: TLSConf{MinVersion: "1.4"}}); err == nil {
+		t.Errorf("不支持的 TLS 版本应返回错误")
+	}
+}
+
+// 测试 NO_PROXY 规则
+func TestNoProxy(t *testing.T) {
+	rules := []string{"example.com", ".internal", "10.0.0.0/8", "192.168.1.1", "api.test:8443"}
+	tests := map[string]bool{
+		"http://example.com/":      true,
+		"http://a.example.com/":    true,
+		"http://badexample.com/":   false,
+		"http://internal/":         false,
+		"http://svc.internal/":     true,
+		"http://10.1.2.3/":         true,
+		"http://192.168.1.1:8080/": true,
+		"http://192.168.1.2/":      false,
+		"https://api.test:8443/":   true,
+		"https://api.test/":        false,
+		"http://other.com/":        false,
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := noProxy(rules, u); got != want {
+			t.Errorf("noProxy(%s) = %v; want %v", raw, got, want)
+		}
+	}
+	u, _ := url.Parse("http://any.com")
+	if !noProxy([]string{"*"}, u) {
+		t.Errorf("* 应匹配所有主机")
+	}
+}
+
+// 测试通过 http 代理发送请求
+func TestTransportConf_Proxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("proxied " + r.URL.String()))
+	}))
+	defer proxy.Close()
+
+	client := NewClient(WithTransportConf(TransportConf{Proxy: ProxyConf{URL: proxy.URL}}))
+	body, err := client.Get(context.Background(), "http://upstream.example/a", nil, nil)
+	if err != nil || string(body) != "proxied http://upstream.example/a" {
+		t.Errorf("Get() = %q, %v", body, err)
+	}
+}
+
+// writePEM 将证书或私钥写入文件
+func writePEM(t *testing.T, path, typ string, data []byte) {
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: data}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// issueCert 使用 CA 签发证书，ca 为空时生成自签名 CA
+func issueCert(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, template *x509.Certificate) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if ca == nil {
+		ca, caKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	return cert, key
+}
+
+// 测试私有 CA 与双向认证
+func TestTransportConf_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := issueCert(t, nil, nil, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test ca"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	})
+	serverCert, serverKey := issueCert(t, ca, caKey, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		DNSNames:    []string{"api.internal"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	clientCert, clientKey := issueCert(t, ca, caKey, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", ca.Raw)
+	writePEM(t, filepath.Join(dir, "client.pem"), "CERTIFICATE", clientCert.Raw)
+	keyDER, _ := x509.MarshalECPrivateKey(clientKey)
+	writePEM(t, filepath.Join(dir, "client.key"), "EC PRIVATE KEY", keyDER)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	tlsConf := TLSConf{
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		CertFile:   filepath.Join(dir, "client.pem"),
+		KeyFile:    filepath.Join(dir, "client.key"),
+		ServerName: "api.internal",
+	}
+	client := NewClient(WithTransportConf(TransportConf{TLS: tlsConf}))
+	body, err := client.Get(context.Background(), server.URL, nil, nil)
+	if err != nil || string(body) != "client" {
+		t.Errorf("Get() = %q, %v", body, err)
+	}
+
+	// 没有客户端证书时握手失败
+	tlsConf.CertFile, tlsConf.KeyFile = "", ""
+	client = NewClient(WithTransportConf(TransportConf{TLS: tlsConf}))
+	if _, err = client.Get(context.Background(), server.URL, nil, nil); err == nil {
+		t.Errorf("没有客户端证书时应返回错误")
+	}
+}